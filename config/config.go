@@ -30,6 +30,84 @@ type Config struct {
 
 	// Crawler configuration
 	CrawlInterval time.Duration
+	// Default deadline of a crawl of one site, detail pages included
+	CrawlTimeout time.Duration
+	// Most pages a crawler fetches while catching up, including the first
+	CatchUpMaxPages int
+	// Most detail pages fetched at once across all crawlers (detail pages are not fetched when zero)
+	DetailConcurrency int
+	// Most thumbnails downloaded at once across all crawlers
+	ImageConcurrency int
+	// How long a downloaded thumbnail is cached by its URL
+	ImageCacheTTL time.Duration
+	// Largest image downloaded, in bytes; larger images are dropped unread
+	ImageMaxBytes int
+	// Where thumbnails are kept: "inline" to embed them in the deal as base64,
+	// as consumers that predate the image store expect, "local" or "s3"
+	ImageStore string
+	// Directory of the local image store
+	ImageStoreDir string
+	// URL the stored images are served from; required for the local store,
+	// while S3 defaults to the bucket's URL
+	ImageBaseURL string
+	// S3-compatible image store
+	ImageS3Endpoint  string
+	ImageS3Bucket    string
+	ImageS3Region    string
+	ImageS3AccessKey string
+	ImageS3SecretKey string
+	// Box thumbnails are scaled down to fit
+	ThumbnailMaxWidth  int
+	ThumbnailMaxHeight int
+	// Smallest width or height of a thumbnail; smaller images are dropped as placeholders
+	ThumbnailMinSize int
+	// JPEG quality thumbnails are re-encoded at
+	ThumbnailQuality int
+	// Largest thumbnail kept, in bytes
+	ThumbnailMaxBytes int
+
+	// How a failed crawl is retried within the same run, unless the site
+	// definition sets its own: attempts including the first, the backoff
+	// doubling from base to max, and the most jitter added to each wait
+	RetryMaxAttempts int
+	RetryBaseBackoff time.Duration
+	RetryMaxBackoff  time.Duration
+	RetryJitter      time.Duration
+
+	// Most outbound requests in flight at once across all hosts
+	FetchConcurrency int
+	// Requests per second sent to each host, in bursts of at most FetchHostBurst;
+	// FetchHostRates overrides the rate for a host and its subdomains
+	FetchHostRate  float64
+	FetchHostBurst int
+	FetchHostRates map[string]float64
+
+	// Deduplication configuration
+	DedupTTL time.Duration
+
+	// Hotness configuration (score at which a deal is announced as trending)
+	HotnessThreshold float64
+
+	// History store configuration (disabled when empty)
+	HistoryDBPath string
+
+	// Address serving runtime metrics at /debug/vars (disabled when empty)
+	MetricsAddr string
+
+	// Directory of site definitions overriding the built-in ones (built-in only when empty)
+	SitesDir string
+	// How often the sites directory is checked for changes (disabled when zero)
+	SitesReloadInterval time.Duration
+	// File of merchant rules extending the built-in ones (built-in only when empty)
+	MerchantsFile string
+
+	// Redirect resolution of shop links (links are not resolved when the hop limit is zero)
+	RedirectMaxHops int
+	// Timeout of each redirect request, overridden per host by RedirectHostTimeouts
+	RedirectTimeout      time.Duration
+	RedirectHostTimeouts map[string]time.Duration
+	// How long a resolved link is cached
+	RedirectCacheTTL time.Duration
 
 	// ChromeDB configuration
 	ChromeDBAddr string
@@ -71,9 +149,86 @@ func (c *Config) Validate() error {
 	if c.CrawlInterval < 10*time.Second {
 		return errors.NewConfiguration("crawl interval must be at least 10 seconds", nil)
 	}
+	if c.CrawlTimeout <= 0 {
+		return errors.NewConfiguration("crawl timeout must be positive", nil)
+	}
 	if c.RedisStreamMaxLength <= 0 {
 		return errors.NewConfiguration("redis stream max length must be positive", nil)
 	}
+	if c.DedupTTL <= 0 || c.DedupTTL > 30*24*time.Hour {
+		return errors.NewConfiguration("dedup ttl must be between 1 hour and 30 days", nil)
+	}
+	if c.CatchUpMaxPages < 1 {
+		return errors.NewConfiguration("catch-up max pages must be at least 1", nil)
+	}
+	if c.DetailConcurrency < 0 {
+		return errors.NewConfiguration("detail concurrency must not be negative", nil)
+	}
+	if c.ImageConcurrency < 1 {
+		return errors.NewConfiguration("image concurrency must be at least 1", nil)
+	}
+	if c.ImageCacheTTL <= 0 {
+		return errors.NewConfiguration("image cache ttl must be positive", nil)
+	}
+	switch c.ImageStore {
+	case "inline":
+	case "local":
+		if c.ImageStoreDir == "" {
+			return errors.NewConfiguration("image store directory is required for the local image store", nil)
+		}
+		// Nothing serves the directory on its own, so deals would carry keys no consumer can load
+		if c.ImageBaseURL == "" {
+			return errors.NewConfiguration("image base url is required for the local image store", nil)
+		}
+	case "s3":
+		if c.ImageS3Endpoint == "" || c.ImageS3Bucket == "" || c.ImageS3AccessKey == "" || c.ImageS3SecretKey == "" {
+			return errors.NewConfiguration("s3 image store requires an endpoint, bucket, access key and secret key", nil)
+		}
+	default:
+		return errors.NewConfiguration(fmt.Sprintf("unknown image store %q", c.ImageStore), nil)
+	}
+	if c.ThumbnailMaxWidth < 1 || c.ThumbnailMaxHeight < 1 {
+		return errors.NewConfiguration("thumbnail max width and height must be at least 1", nil)
+	}
+	if c.ThumbnailMinSize < 1 || c.ThumbnailMinSize > c.ThumbnailMaxWidth || c.ThumbnailMinSize > c.ThumbnailMaxHeight {
+		return errors.NewConfiguration("thumbnail min size must be between 1 and the max width and height", nil)
+	}
+	if c.ThumbnailQuality < 1 || c.ThumbnailQuality > 100 {
+		return errors.NewConfiguration("thumbnail quality must be between 1 and 100", nil)
+	}
+	if c.ThumbnailMaxBytes < 1 {
+		return errors.NewConfiguration("thumbnail max size must be positive", nil)
+	}
+	if c.ImageMaxBytes < 1 {
+		return errors.NewConfiguration("image max download size must be positive", nil)
+	}
+	if c.RetryMaxAttempts < 1 {
+		return errors.NewConfiguration("retry max attempts must be at least 1", nil)
+	}
+	if c.RetryBaseBackoff < 0 || c.RetryMaxBackoff < c.RetryBaseBackoff || c.RetryJitter < 0 {
+		return errors.NewConfiguration("retry backoff must not be negative and max backoff must not be less than base backoff", nil)
+	}
+	if c.FetchConcurrency < 1 {
+		return errors.NewConfiguration("fetch concurrency must be at least 1", nil)
+	}
+	if c.FetchHostRate <= 0 || c.FetchHostBurst < 1 {
+		return errors.NewConfiguration("fetch host rate must be positive and burst at least 1", nil)
+	}
+	if c.SitesReloadInterval < 0 {
+		return errors.NewConfiguration("sites reload interval must not be negative", nil)
+	}
+	if c.RedirectMaxHops < 0 {
+		return errors.NewConfiguration("redirect max hops must not be negative", nil)
+	}
+	if c.RedirectTimeout <= 0 {
+		return errors.NewConfiguration("redirect timeout must be positive", nil)
+	}
+	if c.RedirectCacheTTL <= 0 {
+		return errors.NewConfiguration("redirect cache ttl must be positive", nil)
+	}
+	if c.HotnessThreshold <= 0 {
+		return errors.NewConfiguration("hotness trending threshold must be positive", nil)
+	}
 
 	// Validate at least one crawler is configured
 	enabledCount := 0
@@ -99,8 +254,32 @@ func LoadConfig() Config {
 
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
 	crawlInterval, _ := strconv.Atoi(getEnv("CRAWL_INTERVAL_SECONDS", "60"))
+	crawlTimeout, _ := strconv.Atoi(getEnv("CRAWL_TIMEOUT_SECONDS", "60"))
+	catchUpMaxPages, _ := strconv.Atoi(getEnv("CATCHUP_MAX_PAGES", "5"))
+	detailConcurrency, _ := strconv.Atoi(getEnv("DETAIL_CONCURRENCY", "4"))
+	imageConcurrency, _ := strconv.Atoi(getEnv("IMAGE_CONCURRENCY", "8"))
+	imageCacheTTL, _ := strconv.Atoi(getEnv("IMAGE_CACHE_TTL_HOURS", "24"))
+	imageMaxDownloadKB, _ := strconv.Atoi(getEnv("IMAGE_MAX_DOWNLOAD_KB", "5120"))
+	thumbnailMaxWidth, _ := strconv.Atoi(getEnv("THUMBNAIL_MAX_WIDTH", "480"))
+	thumbnailMaxHeight, _ := strconv.Atoi(getEnv("THUMBNAIL_MAX_HEIGHT", "480"))
+	thumbnailMinSize, _ := strconv.Atoi(getEnv("THUMBNAIL_MIN_SIZE", "16"))
+	thumbnailQuality, _ := strconv.Atoi(getEnv("THUMBNAIL_QUALITY", "80"))
+	thumbnailMaxKB, _ := strconv.Atoi(getEnv("THUMBNAIL_MAX_KB", "100"))
+	retryMaxAttempts, _ := strconv.Atoi(getEnv("RETRY_MAX_ATTEMPTS", "3"))
+	retryBaseBackoff, _ := strconv.ParseFloat(getEnv("RETRY_BASE_BACKOFF_SECONDS", "2"), 64)
+	retryMaxBackoff, _ := strconv.ParseFloat(getEnv("RETRY_MAX_BACKOFF_SECONDS", "30"), 64)
+	retryJitter, _ := strconv.ParseFloat(getEnv("RETRY_JITTER_SECONDS", "1"), 64)
+	fetchConcurrency, _ := strconv.Atoi(getEnv("FETCH_CONCURRENCY", "16"))
+	fetchHostRate, _ := strconv.ParseFloat(getEnv("FETCH_HOST_RATE", "2"), 64)
+	fetchHostBurst, _ := strconv.Atoi(getEnv("FETCH_HOST_BURST", "4"))
 	redisStreamCount, _ := strconv.Atoi(getEnv("REDIS_STREAM_COUNT", "1"))
 	redisStreamMaxLength, _ := strconv.Atoi(getEnv("REDIS_STREAM_MAX_LENGTH", "500"))
+	dedupTTL, _ := strconv.Atoi(getEnv("DEDUP_TTL_HOURS", "72"))
+	sitesReloadInterval, _ := strconv.Atoi(getEnv("SITES_RELOAD_INTERVAL_SECONDS", "30"))
+	redirectMaxHops, _ := strconv.Atoi(getEnv("REDIRECT_MAX_HOPS", "5"))
+	redirectTimeout, _ := strconv.Atoi(getEnv("REDIRECT_TIMEOUT_SECONDS", "5"))
+	redirectCacheTTL, _ := strconv.Atoi(getEnv("REDIRECT_CACHE_TTL_HOURS", "168"))
+	hotnessThreshold, _ := strconv.ParseFloat(getEnv("HOTNESS_TRENDING_THRESHOLD", "3"), 64)
 	environment := getEnv("HOTDEAL_ENVIRONMENT", "development")
 
 	cfg := Config{
@@ -111,6 +290,44 @@ func LoadConfig() Config {
 		RedisStreamMaxLength: redisStreamMaxLength,
 		MemcacheAddr:         getEnv("MEMCACHE_ADDR", "localhost:11211"),
 		CrawlInterval:        time.Duration(crawlInterval) * time.Second,
+		CrawlTimeout:         time.Duration(crawlTimeout) * time.Second,
+		CatchUpMaxPages:      catchUpMaxPages,
+		DetailConcurrency:    detailConcurrency,
+		ImageConcurrency:     imageConcurrency,
+		ImageCacheTTL:        time.Duration(imageCacheTTL) * time.Hour,
+		ImageMaxBytes:        imageMaxDownloadKB * 1024,
+		ImageStore:           getEnv("IMAGE_STORE", "inline"),
+		ImageStoreDir:        getEnv("IMAGE_STORE_DIR", "data/images"),
+		ImageBaseURL:         getEnv("IMAGE_BASE_URL", ""),
+		ImageS3Endpoint:      getEnv("IMAGE_S3_ENDPOINT", ""),
+		ImageS3Bucket:        getEnv("IMAGE_S3_BUCKET", ""),
+		ImageS3Region:        getEnv("IMAGE_S3_REGION", "us-east-1"),
+		ImageS3AccessKey:     getEnv("IMAGE_S3_ACCESS_KEY", ""),
+		ImageS3SecretKey:     getEnv("IMAGE_S3_SECRET_KEY", ""),
+		ThumbnailMaxWidth:    thumbnailMaxWidth,
+		ThumbnailMaxHeight:   thumbnailMaxHeight,
+		ThumbnailMinSize:     thumbnailMinSize,
+		ThumbnailQuality:     thumbnailQuality,
+		ThumbnailMaxBytes:    thumbnailMaxKB * 1024,
+		RetryMaxAttempts:     retryMaxAttempts,
+		RetryBaseBackoff:     time.Duration(retryBaseBackoff * float64(time.Second)),
+		RetryMaxBackoff:      time.Duration(retryMaxBackoff * float64(time.Second)),
+		RetryJitter:          time.Duration(retryJitter * float64(time.Second)),
+		FetchConcurrency:     fetchConcurrency,
+		FetchHostRate:        fetchHostRate,
+		FetchHostBurst:       fetchHostBurst,
+		FetchHostRates:       parseHostRates(getEnv("FETCH_HOST_RATES", "")),
+		DedupTTL:             time.Duration(dedupTTL) * time.Hour,
+		HotnessThreshold:     hotnessThreshold,
+		HistoryDBPath:        getEnv("HISTORY_DB_PATH", ""),
+		MetricsAddr:          getEnv("METRICS_ADDR", ""),
+		SitesDir:             getEnv("SITES_DIR", ""),
+		SitesReloadInterval:  time.Duration(sitesReloadInterval) * time.Second,
+		MerchantsFile:        getEnv("MERCHANTS_FILE", ""),
+		RedirectMaxHops:      redirectMaxHops,
+		RedirectTimeout:      time.Duration(redirectTimeout) * time.Second,
+		RedirectHostTimeouts: parseHostTimeouts(getEnv("REDIRECT_HOST_TIMEOUTS", "")),
+		RedirectCacheTTL:     time.Duration(redirectCacheTTL) * time.Hour,
 		ChromeDBAddr:         getEnv("CHROME_DB_ADDR", "http://localhost:3000"),
 		UseChromeDB:          getEnvBool("USE_CHROME_DB", false),
 		FMKoreaURL:           getEnv("FMKOREA_URL", "https://www.fmkorea.com"),
@@ -188,6 +405,42 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return boolValue
 }
 
+// parseHostTimeouts parses a list of host timeouts in seconds, e.g.
+// "coupa.ng=2,link.coupang.com=3". Malformed entries are skipped.
+func parseHostTimeouts(value string) map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(value, ",") {
+		host, seconds, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(seconds), 64)
+		if err != nil || n <= 0 {
+			continue
+		}
+		timeouts[strings.TrimSpace(host)] = time.Duration(n * float64(time.Second))
+	}
+	return timeouts
+}
+
+// parseHostRates parses a list of host rates in requests per second, e.g.
+// "ppomppu.co.kr=1,cdn.example.com=10". Malformed entries are skipped.
+func parseHostRates(value string) map[string]float64 {
+	rates := make(map[string]float64)
+	for _, entry := range strings.Split(value, ",") {
+		host, rate, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || n <= 0 {
+			continue
+		}
+		rates[strings.TrimSpace(host)] = n
+	}
+	return rates
+}
+
 // toUpper converts string to uppercase for environment variable names
 func toUpper(s string) string {
 	return strings.ToUpper(s)
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -14,6 +15,27 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, 0, config.RedisDB)
 	assert.Equal(t, 1, config.RedisStreamCount)
 	assert.Equal(t, "localhost:11211", config.MemcacheAddr)
+	assert.Equal(t, 3.0, config.HotnessThreshold)
+	assert.Equal(t, 60*time.Second, config.CrawlTimeout)
+	assert.Equal(t, 8, config.ImageConcurrency)
+	assert.Equal(t, 24*time.Hour, config.ImageCacheTTL)
+	assert.Equal(t, 5*1024*1024, config.ImageMaxBytes)
+	assert.Equal(t, "inline", config.ImageStore)
+	assert.Equal(t, "data/images", config.ImageStoreDir)
+	assert.Equal(t, 480, config.ThumbnailMaxWidth)
+	assert.Equal(t, 16, config.ThumbnailMinSize)
+	assert.Equal(t, 100*1024, config.ThumbnailMaxBytes)
+	assert.Equal(t, 5, config.CatchUpMaxPages)
+	assert.Equal(t, 4, config.DetailConcurrency)
+	assert.Equal(t, 5, config.RedirectMaxHops)
+	assert.Equal(t, 5*time.Second, config.RedirectTimeout)
+	assert.Empty(t, config.RedirectHostTimeouts)
+	assert.Equal(t, 16, config.FetchConcurrency)
+	assert.Equal(t, 3, config.RetryMaxAttempts)
+	assert.Equal(t, 2*time.Second, config.RetryBaseBackoff)
+	assert.Equal(t, 30*time.Second, config.RetryMaxBackoff)
+	assert.Equal(t, 2.0, config.FetchHostRate)
+	assert.Equal(t, 4, config.FetchHostBurst)
 
 	// Test with environment variables
 	os.Setenv("REDIS_ADDR", "redis.example.com:6379")
@@ -22,6 +44,8 @@ func TestLoadConfig(t *testing.T) {
 	os.Setenv("MEMCACHE_ADDR", "memcache.example.com:11211")
 	os.Setenv("CRAWL_INTERVAL_SECONDS", "30")
 	os.Setenv("FMKOREA_URL", "https://example.com/fmkorea")
+	os.Setenv("REDIRECT_HOST_TIMEOUTS", "coupa.ng=2, bit.ly=0.5,broken,bad=x")
+	os.Setenv("FETCH_HOST_RATES", "ppomppu.co.kr=1,cdn.example.com=10,zero=0")
 
 	config = LoadConfig()
 	assert.Equal(t, "redis.example.com:6379", config.RedisAddr)
@@ -29,6 +53,14 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, 1, config.RedisStreamCount)
 	assert.Equal(t, "memcache.example.com:11211", config.MemcacheAddr)
 	assert.Equal(t, "https://example.com/fmkorea", config.FMKoreaURL)
+	assert.Equal(t, map[string]time.Duration{
+		"coupa.ng": 2 * time.Second,
+		"bit.ly":   500 * time.Millisecond,
+	}, config.RedirectHostTimeouts)
+	assert.Equal(t, map[string]float64{
+		"ppomppu.co.kr":   1,
+		"cdn.example.com": 10,
+	}, config.FetchHostRates)
 
 	// Clean up
 	os.Unsetenv("REDIS_ADDR")
@@ -37,4 +69,50 @@ func TestLoadConfig(t *testing.T) {
 	os.Unsetenv("MEMCACHE_ADDR")
 	os.Unsetenv("CRAWL_INTERVAL_SECONDS")
 	os.Unsetenv("FMKOREA_URL")
+	os.Unsetenv("REDIRECT_HOST_TIMEOUTS")
+	os.Unsetenv("FETCH_HOST_RATES")
+}
+
+func TestValidateImageStore(t *testing.T) {
+	config := LoadConfig()
+	assert.NoError(t, config.Validate())
+
+	// The local store needs a URL serving its directory
+	config.ImageStore = "local"
+	assert.Error(t, config.Validate())
+	config.ImageBaseURL = "https://img.example.com"
+	assert.NoError(t, config.Validate())
+	config.ImageBaseURL = ""
+
+	// S3 needs its bucket and credentials
+	config.ImageStore = "s3"
+	assert.Error(t, config.Validate())
+	config.ImageS3Endpoint = "https://s3.example.com"
+	config.ImageS3Bucket = "images"
+	config.ImageS3AccessKey = "key"
+	config.ImageS3SecretKey = "secret"
+	assert.NoError(t, config.Validate())
+
+	config.ImageStore = "ftp"
+	assert.Error(t, config.Validate())
+}
+
+func TestValidateThumbnail(t *testing.T) {
+	config := LoadConfig()
+
+	config.ThumbnailQuality = 101
+	assert.Error(t, config.Validate())
+	config.ThumbnailQuality = 80
+
+	// Placeholders cannot be larger than the thumbnails themselves
+	config.ThumbnailMinSize = config.ThumbnailMaxWidth + 1
+	assert.Error(t, config.Validate())
+	config.ThumbnailMinSize = 16
+
+	config.ThumbnailMaxBytes = 0
+	assert.Error(t, config.Validate())
+	config.ThumbnailMaxBytes = 100 * 1024
+
+	config.ImageMaxBytes = 0
+	assert.Error(t, config.Validate())
 }
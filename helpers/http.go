@@ -2,11 +2,14 @@ package helpers
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	mathrand "math/rand"
 	"net/http"
-	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/net/html/charset"
@@ -25,14 +28,86 @@ var (
 		"https://www.daum.net/",
 	}
 
-	// HTTP client with timeout
-	client = &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	// HTTP client with timeout, paced by the host limiter
+	client = NewClient(10 * time.Second)
 )
 
-func FetchSimply(url string, headers ...http.Header) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
+// ErrNotModified is returned by FetchConditional when the page has not
+// changed since the validators were issued
+var ErrNotModified = errors.New("not modified")
+
+// ErrTooLarge is returned by FetchLimited when the body exceeds the limit
+var ErrTooLarge = errors.New("response body too large")
+
+// StatusError is returned when a server answers with a status other than 200 OK
+type StatusError struct {
+	URL        string
+	StatusCode int
+	// RetryAfter is how long the server asked to wait before the next
+	// request; zero when it did not say
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.RateLimited() && e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited; retry after %s", e.RetryAfter)
+	}
+	if e.RateLimited() {
+		return "rate limited"
+	}
+	return fmt.Sprintf("fetch %s unexpected status code: %d", e.URL, e.StatusCode)
+}
+
+// RateLimited reports whether the server refused the request for coming too often
+func (e *StatusError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == 430
+}
+
+// newStatusError describes the response, reading its Retry-After header
+func newStatusError(url string, resp *http.Response) *StatusError {
+	return &StatusError{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP
+// date. It returns zero when the header is missing, malformed or in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// Validators are the cache validators a server issued with a page
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// FetchSimply sends a plain HTTP GET request and returns the response body.
+// The request is abandoned when the context is done.
+func FetchSimply(ctx context.Context, url string, headers ...http.Header) ([]byte, error) {
+	return FetchLimited(ctx, url, 0, headers...)
+}
+
+// FetchLimited works like FetchSimply but reads at most maxBytes of the body,
+// returning ErrTooLarge for larger bodies without reading them any further.
+// A maxBytes of zero or less reads the whole body.
+func FetchLimited(ctx context.Context, url string, maxBytes int64, headers ...http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -51,28 +126,53 @@ func FetchSimply(url string, headers ...http.Header) ([]byte, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		resp.Body.Close()
-		return nil, fmt.Errorf("fetchSimply unexpected status code: %d, url: %s", resp.StatusCode, url)
+		return nil, newStatusError(url, resp)
 	}
 
 	defer resp.Body.Close()
 
-	data, err := io.ReadAll(resp.Body)
+	if maxBytes <= 0 {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		return data, nil
+	}
+
+	if resp.ContentLength > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds %d bytes", ErrTooLarge, resp.ContentLength, maxBytes)
+	}
+	// One byte past the limit tells a body at the limit from a larger one
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrTooLarge, maxBytes)
+	}
 
 	return data, nil
 }
 
 // FetchWithRandomHeaders sends an HTTP GET request with randomized headers,
 // converts the response body to UTF-8 (if needed), and returns it as an io.Reader.
-func FetchWithRandomHeaders(url string) (io.Reader, error) {
+// The request is abandoned when the context is done.
+func FetchWithRandomHeaders(ctx context.Context, url string) (io.Reader, error) {
+	body, _, err := FetchConditional(ctx, url, Validators{})
+	return body, err
+}
+
+// FetchConditional works like FetchWithRandomHeaders but sends the validators
+// from the previous fetch as If-None-Match and If-Modified-Since. It returns
+// ErrNotModified when the server answers that the page has not changed, and
+// the validators issued with the page otherwise.
+func FetchConditional(ctx context.Context, url string, validators Validators) (io.Reader, Validators, error) {
 	// Create a new random number generator for header selection
 	rnd := mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, Validators{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set browser-like headers
@@ -88,23 +188,34 @@ func FetchWithRandomHeaders(url string) (io.Reader, error) {
 	req.Header.Set("Sec-Fetch-Mode", "navigate")
 	req.Header.Set("Sec-Fetch-Site", "cross-site")
 	req.Header.Set("Sec-Fetch-User", "?1")
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
 
 	// Send the request
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+		return nil, Validators{}, fmt.Errorf("failed to fetch URL: %w", err)
 	}
 
-	// Check for rate limiting
-	if slices.Contains([]int{http.StatusTooManyRequests, 430}, resp.StatusCode) {
-		retryAfter := resp.Header.Get("Retry-After")
-		return nil, fmt.Errorf("rate limited; retry after %s", retryAfter)
+	// The page has not changed since the validators were issued
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, validators, ErrNotModified
 	}
 
-	// Check for other error status codes
+	// Check for error status codes, including rate limiting
 	if resp.StatusCode != http.StatusOK {
 		resp.Body.Close()
-		return nil, fmt.Errorf("fetch %s unexpected status code: %d", url, resp.StatusCode)
+		return nil, Validators{}, newStatusError(url, resp)
+	}
+
+	issued := Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
 	}
 
 	defer resp.Body.Close()
@@ -112,7 +223,7 @@ func FetchWithRandomHeaders(url string) (io.Reader, error) {
 	// Read the entire response body
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, Validators{}, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Determine the encoding from Content-Type header and body content
@@ -120,15 +231,15 @@ func FetchWithRandomHeaders(url string) (io.Reader, error) {
 
 	// If already UTF-8, return as is
 	if name == "utf-8" || name == "UTF-8" {
-		return bytes.NewReader(bodyBytes), nil
+		return bytes.NewReader(bodyBytes), issued, nil
 	}
 
 	// Convert to UTF-8 if necessary
 	utf8Reader := encoding.NewDecoder().Reader(bytes.NewReader(bodyBytes))
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, utf8Reader); err != nil {
-		return nil, fmt.Errorf("failed to read converted UTF-8 body: %w", err)
+		return nil, Validators{}, fmt.Errorf("failed to read converted UTF-8 body: %w", err)
 	}
 
-	return &buf, nil
+	return &buf, issued, nil
 }
@@ -1,10 +1,13 @@
 package helpers
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -26,7 +29,7 @@ func TestFetchWithRandomHeaders(t *testing.T) {
 	defer server.Close()
 
 	// Fetch the page
-	reader, err := FetchWithRandomHeaders(server.URL)
+	reader, err := FetchWithRandomHeaders(context.Background(), server.URL)
 	assert.NoError(t, err)
 
 	// Read the response
@@ -47,7 +50,7 @@ func TestFetchWithRandomHeadersNonUTF8(t *testing.T) {
 	defer server.Close()
 
 	// Fetch the page
-	reader, err := FetchWithRandomHeaders(server.URL)
+	reader, err := FetchWithRandomHeaders(context.Background(), server.URL)
 	assert.NoError(t, err)
 
 	// Read the response
@@ -64,7 +67,7 @@ func TestFetchWithRandomHeadersError(t *testing.T) {
 	defer server.Close()
 
 	// Fetch the page
-	_, err := FetchWithRandomHeaders(server.URL)
+	_, err := FetchWithRandomHeaders(context.Background(), server.URL)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unexpected status code: 500")
 
@@ -76,13 +79,117 @@ func TestFetchWithRandomHeadersError(t *testing.T) {
 	defer serverRateLimited.Close()
 
 	// Fetch the page
-	_, err = FetchWithRandomHeaders(serverRateLimited.URL)
+	_, err = FetchWithRandomHeaders(context.Background(), serverRateLimited.URL)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "rate limited")
+
+	var statusErr *StatusError
+	if assert.ErrorAs(t, err, &statusErr) {
+		assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+		assert.Equal(t, 60*time.Second, statusErr.RetryAfter)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"120", 2 * time.Minute},
+		{" 0 ", 0},
+		{"Thu, 16 Oct 2025 12:05:00 GMT", 5 * time.Minute},
+		{"Thu, 16 Oct 2025 11:00:00 GMT", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{"", 0},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, parseRetryAfter(tc.value, now), tc.value)
+	}
 }
 
 func TestFetchWithRandomHeadersInvalidURL(t *testing.T) {
 	// Fetch with an invalid URL
-	_, err := FetchWithRandomHeaders("http://invalid.url.that.does.not.exist")
+	_, err := FetchWithRandomHeaders(context.Background(), "http://invalid.url.that.does.not.exist")
 	assert.Error(t, err)
 }
+
+func TestFetchWithRandomHeadersCancelled(t *testing.T) {
+	// Create a test server that never answers in time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	// The request is abandoned at the deadline instead of waiting for the client timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := FetchWithRandomHeaders(ctx, server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestFetchConditional(t *testing.T) {
+	const etag = `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Wed, 01 May 2024 00:00:00 GMT")
+		w.Write([]byte("<html><body>Deals</body></html>"))
+	}))
+	defer server.Close()
+
+	// The first fetch returns the page with its validators
+	reader, validators, err := FetchConditional(context.Background(), server.URL, Validators{})
+	assert.NoError(t, err)
+	assert.Equal(t, Validators{ETag: etag, LastModified: "Wed, 01 May 2024 00:00:00 GMT"}, validators)
+	body, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "Deals")
+
+	// Sending them back reports the page as unchanged
+	reader, unchanged, err := FetchConditional(context.Background(), server.URL, validators)
+	assert.ErrorIs(t, err, ErrNotModified)
+	assert.Nil(t, reader)
+	assert.Equal(t, validators, unchanged)
+}
+
+func TestFetchLimited(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Chunked responses do not declare their length up front
+		if r.URL.Path == "/chunked" {
+			w.Write([]byte(body[:50]))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(body[50:]))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	data, err := FetchLimited(context.Background(), server.URL, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(data))
+
+	_, err = FetchLimited(context.Background(), server.URL, 99)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = FetchLimited(context.Background(), server.URL+"/chunked", 99)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	// Without a limit the whole body is read
+	data, err = FetchLimited(context.Background(), server.URL+"/chunked", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(data))
+}
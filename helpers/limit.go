@@ -0,0 +1,242 @@
+package helpers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimiter paces every outbound request made through the helpers; it is nil until SetHostLimiter
+var (
+	hostLimiterMu sync.RWMutex
+	hostLimiter   *HostLimiter
+)
+
+// HostLimiter keeps the worker polite: requests to each host are paced by a
+// token bucket, and the requests in flight across all hosts are capped
+type HostLimiter struct {
+	rate      float64
+	burst     int
+	hostRates map[string]float64
+	slots     chan struct{}
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	// now returns the current time; replaced in tests
+	now func() time.Time
+}
+
+// tokenBucket holds the tokens left for one host
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// NewHostLimiter creates a limiter allowing concurrency requests in flight
+// and rate requests per second to each host, in bursts of at most burst.
+// hostRates overrides the rate for a host and its subdomains.
+func NewHostLimiter(concurrency int, rate float64, burst int, hostRates map[string]float64) *HostLimiter {
+	rates := make(map[string]float64, len(hostRates))
+	for host, hostRate := range hostRates {
+		rates[strings.ToLower(strings.TrimPrefix(host, "www."))] = hostRate
+	}
+
+	return &HostLimiter{
+		rate:      rate,
+		burst:     burst,
+		hostRates: rates,
+		slots:     make(chan struct{}, concurrency),
+		buckets:   make(map[string]*tokenBucket),
+		now:       time.Now,
+	}
+}
+
+// SetHostLimiter makes every outbound request made through the helpers wait
+// for the limiter; nil removes the limits
+func SetHostLimiter(l *HostLimiter) {
+	hostLimiterMu.Lock()
+	defer hostLimiterMu.Unlock()
+	hostLimiter = l
+}
+
+// currentHostLimiter returns the limiter set by SetHostLimiter
+func currentHostLimiter() *HostLimiter {
+	hostLimiterMu.RLock()
+	defer hostLimiterMu.RUnlock()
+	return hostLimiter
+}
+
+// WaitHost waits until a request may be sent to the host of the URL. It is for
+// requests that reach the host through a proxy such as ChromeDB, so the host
+// is paced like a direct request would be. It returns at once without a limiter.
+func WaitHost(ctx context.Context, rawURL string) error {
+	l := currentHostLimiter()
+	if l == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	return l.Wait(ctx, u.Hostname())
+}
+
+// NewClient returns an HTTP client whose requests wait for the host limiter
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: Transport(),
+	}
+}
+
+// Transport returns a transport whose requests wait for the host limiter,
+// for clients that need more settings than NewClient offers
+func Transport() http.RoundTripper {
+	return LimitTransport(http.DefaultTransport)
+}
+
+// LimitTransport returns a transport sending requests through base once the
+// host limiter allows them, for clients that need their own transport
+func LimitTransport(base http.RoundTripper) http.RoundTripper {
+	return limitedTransport{base: base}
+}
+
+// Wait takes a token from the host's bucket, waiting until one is available.
+// It gives up when the context is done.
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	delay := l.reserve(host)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel(host)
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token from the host's bucket, going into debt when it is
+// empty, and returns how long to wait until the token is due
+func (l *HostLimiter) reserve(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	host = strings.ToLower(strings.TrimPrefix(host, "www."))
+	now := l.now()
+	bucket, ok := l.buckets[host]
+	if !ok {
+		bucket = &tokenBucket{rate: l.rateFor(host), tokens: float64(l.burst), last: now}
+		l.buckets[host] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.last).Seconds() * bucket.rate
+	if bucket.tokens > float64(l.burst) {
+		bucket.tokens = float64(l.burst)
+	}
+	bucket.last = now
+
+	bucket.tokens--
+	if bucket.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bucket.tokens / bucket.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token that was not used
+func (l *HostLimiter) cancel(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if bucket, ok := l.buckets[strings.ToLower(strings.TrimPrefix(host, "www."))]; ok {
+		bucket.tokens++
+	}
+}
+
+// rateFor returns the rate of the host, looking up its parent domains too
+func (l *HostLimiter) rateFor(host string) float64 {
+	for host != "" {
+		if rate, ok := l.hostRates[host]; ok {
+			return rate
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return l.rate
+}
+
+// acquire takes one of the slots for requests in flight
+func (l *HostLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire
+func (l *HostLimiter) release() {
+	<-l.slots
+}
+
+// limitedTransport sends requests once the host limiter allows them. A
+// request holds its slot until its response body is closed.
+type limitedTransport struct {
+	base http.RoundTripper
+}
+
+func (t limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := currentHostLimiter()
+	if l == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	if err := l.Wait(req.Context(), req.URL.Hostname()); err != nil {
+		closeBody(req)
+		return nil, err
+	}
+	if err := l.acquire(req.Context()); err != nil {
+		closeBody(req)
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		l.release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: sync.OnceFunc(l.release)}
+	return resp, nil
+}
+
+// closeBody closes the body of a request that is not sent, as a RoundTripper must
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// releasingBody frees the request's slot when the body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
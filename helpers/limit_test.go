@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostLimiterReserve(t *testing.T) {
+	now := time.Date(2025, 10, 16, 12, 0, 0, 0, time.UTC)
+	l := NewHostLimiter(4, 1, 2, map[string]float64{"slow.example.com": 0.5})
+	l.now = func() time.Time { return now }
+
+	// A burst goes out at once, then requests wait for the bucket to refill
+	assert.Equal(t, time.Duration(0), l.reserve("www.example.com"))
+	assert.Equal(t, time.Duration(0), l.reserve("example.com"))
+	assert.Equal(t, time.Second, l.reserve("example.com"))
+	assert.Equal(t, 2*time.Second, l.reserve("example.com"))
+
+	// Hosts have their own buckets
+	assert.Equal(t, time.Duration(0), l.reserve("other.com"))
+
+	// Refilled tokens pay off the debt first
+	now = now.Add(3 * time.Second)
+	assert.Equal(t, time.Duration(0), l.reserve("example.com"))
+
+	// Host rates apply to subdomains
+	assert.Equal(t, time.Duration(0), l.reserve("img.slow.example.com"))
+	assert.Equal(t, time.Duration(0), l.reserve("img.slow.example.com"))
+	assert.Equal(t, 2*time.Second, l.reserve("img.slow.example.com"))
+}
+
+func TestHostLimiterWaitCancelled(t *testing.T) {
+	l := NewHostLimiter(4, 0.001, 1, nil)
+	assert.NoError(t, l.Wait(context.Background(), "example.com"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx, "example.com"), context.DeadlineExceeded)
+
+	// The cancelled wait gave its token back
+	assert.InDelta(t, 0, l.buckets["example.com"].tokens, 0.01)
+}
+
+func TestLimitedTransportConcurrency(t *testing.T) {
+	var inFlight, most int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&most)
+			if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	SetHostLimiter(NewHostLimiter(2, 1000, 100, nil))
+	defer SetHostLimiter(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := FetchSimply(context.Background(), server.URL)
+			assert.NoError(t, err)
+			assert.Equal(t, "ok", string(data))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&most))
+}
+
+func TestLimitedTransportReleasesRefusedRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/shopify":
+			w.WriteHeader(430)
+		case "/same":
+			w.WriteHeader(http.StatusNotModified)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	SetHostLimiter(NewHostLimiter(2, 1000, 100, nil))
+	defer SetHostLimiter(nil)
+
+	// Refused requests free their slot, so they cannot use up the limiter
+	for _, path := range []string{"/limited", "/shopify", "/same", "/missing", "/limited", "/shopify"} {
+		_, _, err := FetchConditional(context.Background(), server.URL+path, Validators{ETag: `"v1"`})
+		assert.Error(t, err, path)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _, err := FetchConditional(ctx, server.URL, Validators{})
+	assert.NoError(t, err)
+}
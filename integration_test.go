@@ -106,8 +106,8 @@ func (c *TestCrawler) GetProvider() string {
 	return "Test"
 }
 
-func (c *TestCrawler) FetchDeals() ([]crawler.HotDeal, error) {
-	utf8Body, err := helpers.FetchWithRandomHeaders(c.URL)
+func (c *TestCrawler) FetchDeals(ctx context.Context) ([]crawler.HotDeal, error) {
+	utf8Body, err := helpers.FetchWithRandomHeaders(ctx, c.URL)
 	if err != nil {
 		return nil, err
 	}
@@ -268,7 +268,7 @@ func TestIntegration(t *testing.T) {
 	// Create a separate goroutine for publishing to avoid blocking
 	go func() {
 		// Fetch deals from the crawler
-		deals, err := testCrawler.FetchDeals()
+		deals, err := testCrawler.FetchDeals(ctx)
 		if err != nil {
 			errChan <- fmt.Errorf("failed to fetch deals: %w", err)
 			return
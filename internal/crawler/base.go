@@ -1,18 +1,26 @@
 package crawler
 
 import (
+	"context"
 	"encoding/base64"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"sjsage522/hotdealworker/helpers"
+	"sjsage522/hotdealworker/internal/urlcanon"
 	"sjsage522/hotdealworker/logger"
+	"sjsage522/hotdealworker/pkg/errors"
+	"sjsage522/hotdealworker/services/cache"
+	"sjsage522/hotdealworker/services/thumbnail"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -25,19 +33,20 @@ import (
 func (c *BaseCrawler) createDocument(reader io.Reader) (*goquery.Document, error) {
 	doc, err := goquery.NewDocumentFromReader(reader)
 	if err != nil {
-		return nil, fmt.Errorf("HTML 파싱 오류: %v", err)
+		return nil, errors.NewParsing(c.Provider, "HTML 파싱 오류", err)
 	}
 	return doc, nil
 }
 
-// processDeals processes deals in parallel using goroutines
+// processDeals processes deals in parallel using goroutines.
+// The returned deals keep the order in which they appear on the page.
 func (c *BaseCrawler) processDeals(selections *goquery.Selection, processor ProcessorFunc) []HotDeal {
-	dealChan := make(chan *HotDeal, selections.Length())
+	results := make([]*HotDeal, selections.Length())
 	var wg sync.WaitGroup
 
 	selections.Each(func(i int, s *goquery.Selection) {
 		wg.Add(1)
-		go func(s *goquery.Selection) {
+		go func(i int, s *goquery.Selection) {
 			defer wg.Done()
 
 			deal, err := processor(s)
@@ -46,18 +55,15 @@ func (c *BaseCrawler) processDeals(selections *goquery.Selection, processor Proc
 				return
 			}
 
-			if deal != nil {
-				dealChan <- deal
-			}
-		}(s)
+			results[i] = deal
+		}(i, s)
 	})
 
 	wg.Wait()
-	close(dealChan)
 
 	// Collect the processed deals
 	var deals []HotDeal
-	for deal := range dealChan {
+	for _, deal := range results {
 		if deal != nil {
 			deals = append(deals, *deal)
 		}
@@ -83,6 +89,43 @@ func (c *BaseCrawler) GetProvider() string {
 	return c.Provider
 }
 
+// CrawlTimeout returns how long a crawl of the site may take
+func (c *BaseCrawler) CrawlTimeout() time.Duration {
+	return c.Timeout
+}
+
+// CrawlSchedule returns how often the crawler runs
+func (c *BaseCrawler) CrawlSchedule() Schedule {
+	return c.Schedule
+}
+
+// CrawlRetry returns how a failed crawl is retried
+func (c *BaseCrawler) CrawlRetry() RetryPolicy {
+	return c.Retry
+}
+
+// CommitFetch makes the latest fetch of the first page the one the next
+// fetch is compared with
+func (c *BaseCrawler) CommitFetch() {
+	if c.firstPage != nil {
+		c.firstPage.commit()
+	}
+}
+
+// ResolveLink resolves a relative post link against the base URL and strips
+// the tracking, affiliate and session parameters from the result, keeping the
+// post keys of the site's own hosts
+func (c *BaseCrawler) ResolveLink(href string) string {
+	link := c.ResolveURL(href)
+	var hosts []string
+	for _, site := range []string{c.URL, c.BaseURL} {
+		if u, err := url.Parse(site); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Hostname())
+		}
+	}
+	return urlcanon.CanonicalizePost(link, hosts...)
+}
+
 // ResolveURL resolves a relative URL against the base URL
 func (c *BaseCrawler) ResolveURL(href string) string {
 	if href == "" {
@@ -125,6 +168,19 @@ func (c *BaseCrawler) ResolveURL(href string) string {
 	return base.ResolveReference(ref).String()
 }
 
+// PageURLFor returns the URL of the given page, starting at 1.
+// It returns an empty string when the page cannot be fetched.
+func (c *BaseCrawler) PageURLFor(page int) string {
+	switch {
+	case page <= 1:
+		return c.URL
+	case c.PageURL == "":
+		return ""
+	default:
+		return strings.ReplaceAll(c.PageURL, "{page}", strconv.Itoa(page+c.PageOffset))
+	}
+}
+
 // ExtractPrice extracts the price from a title using the configured regex
 func (c *BaseCrawler) ExtractPrice(title string) (string, string) {
 	if c.PriceRegex == "" || title == "" {
@@ -140,29 +196,163 @@ func (c *BaseCrawler) ExtractPrice(title string) (string, string) {
 	return title, ""
 }
 
-// ProcessImage fetches an image and converts it to base64
-func (c *BaseCrawler) ProcessImage(imageURL string) (string, string, error) {
+// LoadThumbnail downloads the deal's thumbnail. With an image store the
+// deal carries the stored image's key and URL, otherwise the image itself in
+// base64. Deals without a thumbnail URL, or whose thumbnail is already loaded,
+// are left as they are. Images that are not usable thumbnails are rejected
+// with a *thumbnail.RejectedError.
+func (c *BaseCrawler) LoadThumbnail(ctx context.Context, deal *HotDeal) error {
+	if deal.ThumbnailLink == "" || deal.Thumbnail != "" || deal.ThumbnailKey != "" {
+		return nil
+	}
+
+	if c.ImageLimiter != nil {
+		if err := c.ImageLimiter.Acquire(ctx); err != nil {
+			return err
+		}
+		defer c.ImageLimiter.Release()
+	}
+
+	if c.Images == nil {
+		thumbnail, _, err := c.ProcessImage(ctx, deal.ThumbnailLink)
+		if err != nil {
+			return err
+		}
+		deal.Thumbnail = thumbnail
+		return nil
+	}
+
+	key, err := c.StoreImage(ctx, deal.ThumbnailLink)
+	if err != nil {
+		return err
+	}
+	deal.ThumbnailKey = key
+	deal.ThumbnailURL = c.Images.URL(key)
+	return nil
+}
+
+// StoreImage fetches an image, normalizes it into the image store and returns its key.
+// Keys are cached by URL so each image is downloaded once.
+func (c *BaseCrawler) StoreImage(ctx context.Context, imageURL string) (string, error) {
+	imageURL = c.ResolveURL(imageURL)
+	if imageURL == "" {
+		return "", nil
+	}
+
+	cacheKey := storedImageKey(imageURL)
+	if c.CacheSvc != nil && c.ImageCacheTTL > 0 {
+		if data, err := c.CacheSvc.Get(cacheKey); err == nil && len(data) > 0 {
+			return string(data), nil
+		}
+	}
+
+	data, err := c.loadImage(ctx, imageURL)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := c.Images.Put(ctx, data)
+	if err != nil {
+		return "", fmt.Errorf("error storing image: %w", err)
+	}
+
+	if c.CacheSvc != nil && c.ImageCacheTTL > 0 {
+		// A cache outage only costs another download later
+		_ = c.CacheSvc.Set(cacheKey, []byte(key), c.ImageCacheTTL)
+	}
+	return key, nil
+}
+
+// ProcessImage fetches an image, normalizes it and converts it to base64.
+// Encoded images are cached by URL so each image is downloaded once.
+func (c *BaseCrawler) ProcessImage(ctx context.Context, imageURL string) (string, string, error) {
 	imageURL = c.ResolveURL(imageURL)
 	if imageURL == "" {
 		return "", "", nil
 	}
 
+	key := imageKey(imageURL)
+	if c.CacheSvc != nil && c.ImageCacheTTL > 0 {
+		if data, err := c.CacheSvc.Get(key); err == nil && len(data) > 0 {
+			return string(data), imageURL, nil
+		}
+	}
+
+	data, err := c.loadImage(ctx, imageURL)
+	if err != nil {
+		return "", imageURL, err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	if c.CacheSvc != nil && c.ImageCacheTTL > 0 {
+		// A cache outage only costs another download later
+		_ = c.CacheSvc.Set(key, []byte(encoded), c.ImageCacheTTL)
+	}
+	return encoded, imageURL, nil
+}
+
+// loadImage downloads an image and normalizes it into a thumbnail.
+// Rejections are cached by URL, so an unusable image is not downloaded again.
+func (c *BaseCrawler) loadImage(ctx context.Context, imageURL string) ([]byte, error) {
+	rejectedKey := rejectedImageKey(imageURL)
+	if c.CacheSvc != nil && c.ImageCacheTTL > 0 {
+		if data, err := c.CacheSvc.Get(rejectedKey); err == nil && len(data) > 0 {
+			reason, detail, _ := strings.Cut(string(data), "\n")
+			return nil, &thumbnail.RejectedError{Reason: reason, Detail: detail}
+		}
+	}
+
+	data, err := c.fetchImage(ctx, imageURL)
+	if err == nil && c.Thumbnails != nil {
+		data, err = c.Thumbnails.Normalize(data)
+	}
+
+	var rejected *thumbnail.RejectedError
+	if stderrors.As(err, &rejected) && c.CacheSvc != nil && c.ImageCacheTTL > 0 {
+		// A cache outage only costs another download later
+		_ = c.CacheSvc.Set(rejectedKey, []byte(rejected.Reason+"\n"+rejected.Detail), c.ImageCacheTTL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// fetchImage downloads an image, sending the referer for sites that check it.
+// Images over the download cap are rejected before they are read into memory.
+func (c *BaseCrawler) fetchImage(ctx context.Context, imageURL string) ([]byte, error) {
 	var data []byte
 	var err error
-	if c.Provider == "Bbasak" {
-		data, err = helpers.FetchSimply(imageURL, http.Header{
-			"Referer": []string{"https://bbasak.com/bbs/board.php?bo_table=bbasak1"},
+	if c.ImageReferer != "" {
+		data, err = helpers.FetchLimited(ctx, imageURL, c.ImageMaxBytes, http.Header{
+			"Referer": []string{c.ImageReferer},
 		})
 	} else {
-		data, err = helpers.FetchSimply(imageURL)
+		data, err = helpers.FetchLimited(ctx, imageURL, c.ImageMaxBytes)
 	}
 
+	if stderrors.Is(err, helpers.ErrTooLarge) {
+		return nil, &thumbnail.RejectedError{Reason: thumbnail.ReasonTooLarge, Detail: err.Error()}
+	}
 	if err != nil {
-		logger.Warn("Error fetching image: %v", err)
-		return "", "", nil
+		return nil, fmt.Errorf("error fetching image: %w", err)
 	}
+	return data, nil
+}
+
+// storedImageKey returns the cache key holding the image store key of the image
+func storedImageKey(imageURL string) string {
+	return cache.Key("image-key:", imageURL)
+}
+
+// imageKey returns the cache key holding the encoded image
+func imageKey(imageURL string) string {
+	return cache.Key("image:", imageURL)
+}
 
-	return base64.StdEncoding.EncodeToString(data), imageURL, nil
+// rejectedImageKey returns the cache key holding why the image was rejected
+func rejectedImageKey(imageURL string) string {
+	return cache.Key("image-rejected:", imageURL)
 }
 
 // CreateDeal creates a HotDeal with the given properties
@@ -180,6 +370,34 @@ func (c *BaseCrawler) CreateDeal(id, title, link, price, thumbnail, thumbnailLin
 	}
 }
 
+// statusMarkerRegex matches bracketed status markers such as "[종료]" or "(품절)"
+var statusMarkerRegex = regexp.MustCompile(`[\[\(【]\s*(종료|마감|품절|매진)\s*[\]\)】]`)
+
+// DetectStatusFromTitle detects a deal's lifecycle status from markers in its title
+func DetectStatusFromTitle(title string) string {
+	marker := ""
+	if match := statusMarkerRegex.FindStringSubmatch(title); len(match) > 1 {
+		marker = match[1]
+	} else {
+		// Some boards prefix the title instead of using brackets, e.g. "종료 - ..."
+		for _, keyword := range []string{"종료", "마감", "품절", "매진"} {
+			if strings.HasPrefix(strings.TrimSpace(title), keyword+" ") {
+				marker = keyword
+				break
+			}
+		}
+	}
+
+	switch marker {
+	case "종료", "마감":
+		return StatusEnded
+	case "품절", "매진":
+		return StatusSoldOut
+	default:
+		return StatusActive
+	}
+}
+
 // ExtractURLFromStyle extracts a URL from a CSS style attribute
 func (c *BaseCrawler) ExtractURLFromStyle(style string) string {
 	re := regexp.MustCompile(`url\((?:['"]?)(.*?)(?:['"]?)\)`)
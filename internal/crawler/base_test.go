@@ -1,11 +1,19 @@
 package crawler
 
 import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"sjsage522/hotdealworker/services/imagestore"
+	"sjsage522/hotdealworker/services/thumbnail"
+
 	"github.com/PuerkitoBio/goquery"
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +75,143 @@ func TestGetName(t *testing.T) {
 	name := crawler.GetName()
 	assert.Equal(t, "BaseCrawler", name)
 }
+
+// TestProcessDealsKeepsOrder tests that deals are returned in page order
+func TestProcessDealsKeepsOrder(t *testing.T) {
+	crawler := BaseCrawler{}
+
+	html := `<html><body>
+		<div class="deal">1</div>
+		<div class="deal">2</div>
+		<div class="deal">3</div>
+		<div class="deal">4</div>
+	</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	assert.NoError(t, err)
+
+	deals := crawler.processDeals(doc.Find("div.deal"), func(s *goquery.Selection) (*HotDeal, error) {
+		return &HotDeal{Id: s.Text()}, nil
+	})
+
+	assert.Equal(t, 4, len(deals))
+	for i, deal := range deals {
+		assert.Equal(t, strconv.Itoa(i+1), deal.Id)
+	}
+}
+
+// TestLoadThumbnail tests that thumbnails are downloaded once and then served from the cache
+func TestLoadThumbnail(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing.jpg" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	crawler := BaseCrawler{
+		CacheSvc:      NewMockCacheService(),
+		ImageLimiter:  NewLimiter(1),
+		ImageCacheTTL: time.Hour,
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte("image"))
+
+	deal := HotDeal{ThumbnailLink: server.URL + "/thumb.jpg"}
+	assert.NoError(t, crawler.LoadThumbnail(context.Background(), &deal))
+	assert.Equal(t, encoded, deal.Thumbnail)
+
+	// The same image for another deal comes from the cache
+	other := HotDeal{ThumbnailLink: server.URL + "/thumb.jpg"}
+	assert.NoError(t, crawler.LoadThumbnail(context.Background(), &other))
+	assert.Equal(t, encoded, other.Thumbnail)
+	assert.Equal(t, 1, requests)
+
+	// Deals without a thumbnail URL are left alone
+	empty := HotDeal{}
+	assert.NoError(t, crawler.LoadThumbnail(context.Background(), &empty))
+	assert.Equal(t, 1, requests)
+
+	missing := HotDeal{ThumbnailLink: server.URL + "/missing.jpg"}
+	assert.Error(t, crawler.LoadThumbnail(context.Background(), &missing))
+	assert.Empty(t, missing.Thumbnail)
+
+	// Downloads that are not images are dropped
+	crawler.Thumbnails = thumbnail.NewNormalizer(thumbnail.Options{MaxWidth: 480, MaxHeight: 480, MinSize: 16, Quality: 80, MaxBytes: 1024})
+	text := HotDeal{ThumbnailLink: server.URL + "/text.jpg"}
+	var rejected *thumbnail.RejectedError
+	assert.ErrorAs(t, crawler.LoadThumbnail(context.Background(), &text), &rejected)
+	assert.Equal(t, thumbnail.ReasonUnsupported, rejected.Reason)
+	assert.Empty(t, text.Thumbnail)
+
+	// The rejection is cached, so the image is not downloaded again
+	requests = 0
+	again := HotDeal{ThumbnailLink: server.URL + "/text.jpg"}
+	rejected = nil
+	assert.ErrorAs(t, crawler.LoadThumbnail(context.Background(), &again), &rejected)
+	assert.Equal(t, thumbnail.ReasonUnsupported, rejected.Reason)
+	assert.Equal(t, "text/plain; charset=utf-8", rejected.Detail)
+	assert.Equal(t, 0, requests)
+
+	// Images over the download cap are dropped without reading them
+	crawler.ImageMaxBytes = 4
+	large := HotDeal{ThumbnailLink: server.URL + "/large.jpg"}
+	rejected = nil
+	assert.ErrorAs(t, crawler.LoadThumbnail(context.Background(), &large), &rejected)
+	assert.Equal(t, thumbnail.ReasonTooLarge, rejected.Reason)
+	assert.Empty(t, large.Thumbnail)
+}
+
+func TestLoadThumbnailToStore(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	store := imagestore.NewLocalStore(t.TempDir(), "https://img.example.com")
+	crawler := BaseCrawler{
+		CacheSvc:      NewMockCacheService(),
+		ImageLimiter:  NewLimiter(1),
+		ImageCacheTTL: time.Hour,
+		Images:        store,
+	}
+	key := imagestore.Key([]byte("image"))
+
+	deal := HotDeal{ThumbnailLink: server.URL + "/thumb.jpg"}
+	assert.NoError(t, crawler.LoadThumbnail(context.Background(), &deal))
+	assert.Empty(t, deal.Thumbnail)
+	assert.Equal(t, key, deal.ThumbnailKey)
+	assert.Equal(t, "https://img.example.com/"+key, deal.ThumbnailURL)
+
+	// The key of an image already stored comes from the cache
+	other := HotDeal{ThumbnailLink: server.URL + "/thumb.jpg"}
+	assert.NoError(t, crawler.LoadThumbnail(context.Background(), &other))
+	assert.Equal(t, key, other.ThumbnailKey)
+	assert.Equal(t, 1, requests)
+}
+
+// TestDetectStatusFromTitle tests lifecycle status detection from title markers
+func TestDetectStatusFromTitle(t *testing.T) {
+	testCases := []struct {
+		title  string
+		status string
+	}{
+		{"[쿠팡] 신라면 40봉 (19,900원)", StatusActive},
+		{"[종료] [쿠팡] 신라면 40봉 (19,900원)", StatusEnded},
+		{"[쿠팡] 신라면 40봉 (19,900원) (마감)", StatusEnded},
+		{"[품절] 에어팟 프로", StatusSoldOut},
+		{"에어팟 프로 【매진】", StatusSoldOut},
+		{"종료 - 에어팟 프로", StatusEnded},
+		{"에어팟 프로 품절임박", StatusActive},
+		{"이벤트 종료 임박 할인", StatusActive},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.status, DetectStatusFromTitle(tc.title), tc.title)
+	}
+}
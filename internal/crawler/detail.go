@@ -0,0 +1,179 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"sjsage522/hotdealworker/internal/urlcanon"
+	"sjsage522/hotdealworker/logger"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// maxBodyLength is the most characters of the body text kept on a deal
+	maxBodyLength = 2000
+	// maxDetailImages is the most images kept from a detail page
+	maxDetailImages = 10
+)
+
+// imageAttrs are the attributes an image URL is read from, in order.
+// Lazy-loaded images keep the real URL in a data attribute.
+var imageAttrs = []string{"data-original", "data-src", "src"}
+
+// Limiter caps how many operations run at once
+type Limiter chan struct{}
+
+// NewLimiter creates a limiter that lets n operations run at once
+func NewLimiter(n int) Limiter {
+	return make(Limiter, n)
+}
+
+// Acquire blocks until a slot is free. It gives up when the context is done,
+// so an abandoned crawl does not take a slot.
+func (l Limiter) Acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (l Limiter) Release() {
+	<-l
+}
+
+// HasDetail reports whether the crawler has selectors for detail pages
+func (c *UnifiedCrawler) HasDetail() bool {
+	d := c.Detail
+	return len(d.ShopURLHandlers) > 0 || len(d.ShippingHandlers) > 0 ||
+		len(d.BodyHandlers) > 0 || d.Images != ""
+}
+
+// EnrichDeal fetches the deal's detail page with the crawler's fetch strategy
+// and fills in the shop URL and merchant, shipping conditions, body text and
+// images found there.
+// Fields the detail page does not have are left as they are.
+func (c *UnifiedCrawler) EnrichDeal(ctx context.Context, deal *HotDeal) error {
+	if !c.HasDetail() || deal.Link == "" {
+		return nil
+	}
+
+	if c.DetailLimiter != nil {
+		if err := c.DetailLimiter.Acquire(ctx); err != nil {
+			return err
+		}
+		defer c.DetailLimiter.Release()
+	}
+
+	utf8Body, err := c.fetchFunc(ctx, deal.Link)
+	if err != nil {
+		return fmt.Errorf("상세 페이지 요청 오류: %w", err)
+	}
+
+	doc, err := c.createDocument(utf8Body)
+	if err != nil {
+		return err
+	}
+	page := doc.Selection
+
+	if shopURL := c.applyHandlers(page, c.Detail.ShopURLHandlers); shopURL != "" {
+		shopURL = resolveAgainst(deal.Link, shopURL)
+		deal.ShopURL = urlcanon.Canonicalize(shopURL)
+
+		// Short links are followed as they were posted, since stripping their
+		// parameters may break the redirect
+		if c.Redirects != nil {
+			if final, err := c.Redirects.Resolve(ctx, shopURL); err != nil {
+				logger.Warn("[%s] Failed to resolve shop link %s: %v", c.Provider, shopURL, err)
+			} else {
+				deal.ShopFinalURL = urlcanon.Canonicalize(final)
+			}
+		}
+
+		// The shop link is more reliable than the title prefix, and the page
+		// it lands on more than the link itself
+		if c.Merchants != nil {
+			for _, link := range []string{deal.ShopFinalURL, deal.ShopURL} {
+				if code := c.Merchants.FromURL(link); code != "" {
+					deal.Merchant = code
+					break
+				}
+			}
+		}
+	}
+
+	if shipping := c.applyHandlers(page, c.Detail.ShippingHandlers); shipping != "" {
+		deal.ShippingInfo = strings.Join(strings.Fields(shipping), " ")
+		if deal.ShippingFee == nil {
+			deal.ShippingFee = ParseShippingFee(deal.ShippingInfo)
+		}
+	}
+
+	if body := c.applyHandlers(page, c.Detail.BodyHandlers); body != "" {
+		deal.Body = truncateRunes(strings.Join(strings.Fields(body), " "), maxBodyLength)
+	}
+
+	if c.Detail.Images != "" {
+		deal.Images = detailImages(page.Find(c.Detail.Images), deal.Link)
+
+		// Deals listed without a thumbnail use the first image of the post
+		if deal.ThumbnailLink == "" && len(deal.Images) > 0 {
+			deal.ThumbnailLink = c.ResolveURL(deal.Images[0])
+		}
+	}
+
+	return nil
+}
+
+// detailImages returns the absolute URLs of the images, without duplicates
+func detailImages(images *goquery.Selection, pageURL string) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	images.EachWithBreak(func(_ int, img *goquery.Selection) bool {
+		for _, attr := range imageAttrs {
+			src := strings.TrimSpace(img.AttrOr(attr, ""))
+			if src == "" || strings.HasPrefix(src, "data:") {
+				continue
+			}
+
+			src = resolveAgainst(pageURL, src)
+			if !seen[src] {
+				seen[src] = true
+				result = append(result, src)
+			}
+			break
+		}
+		return len(result) < maxDetailImages
+	})
+
+	return result
+}
+
+// resolveAgainst resolves a link found on a page against the page's URL
+func resolveAgainst(pageURL, href string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// truncateRunes cuts the text to at most n characters
+func truncateRunes(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:n])
+}
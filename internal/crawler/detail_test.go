@@ -0,0 +1,143 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/internal/merchant"
+	"sjsage522/hotdealworker/services/redirect"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrichDeal(t *testing.T) {
+	def, err := ParseSiteDefinition([]byte(`
+name: test
+provider: TestProvider
+site_url: https://example.com
+selectors:
+  deal_list: div.deal
+  link: a
+  title: a
+detail:
+  shop_url:
+    selector: div.info a.shop
+    attr: href
+  shipping:
+    selector: div.info
+    regex: '배송\s*[:：]?\s*(\S[^\n]*)'
+  body: div.content
+  images: div.content img
+`))
+	assert.NoError(t, err)
+
+	cfg := def.CrawlerConfig("", "")
+	cfg.DetailLimiter = NewLimiter(1)
+	cfg.Merchants = merchant.Default()
+	crawler := NewUnifiedCrawler(cfg, nil)
+	assert.True(t, crawler.HasDetail())
+
+	var fetched []string
+	crawler.fetchFunc = func(_ context.Context, pageURL string) (io.Reader, error) {
+		fetched = append(fetched, pageURL)
+		html := `<html><body>
+			<div class="info">
+				<a class="shop" href="https://link.coupang.com/a/1?lptag=AF1&subid=me">쇼핑몰</a>
+				<p>배송: 무료</p>
+			</div>
+			<div class="content">
+				<p>한정   수량
+				특가</p>
+				<img src="/img/1.jpg">
+				<img data-src="/img/2.jpg" src="data:image/gif;base64,R0lGOD">
+				<img src="/img/1.jpg">
+			</div>
+		</body></html>`
+		return strings.NewReader(html), nil
+	}
+
+	deal := HotDeal{Id: "1", Title: "[G마켓] Deal", Link: "https://example.com/board/1", ThumbnailLink: "https://example.com/thumb/1.jpg", Merchant: "gmarket"}
+	assert.NoError(t, crawler.EnrichDeal(context.Background(), &deal))
+	assert.Equal(t, []string{"https://example.com/board/1"}, fetched)
+
+	assert.Equal(t, "https://link.coupang.com/a/1", deal.ShopURL)
+	// The shop link overrides the merchant taken from the title
+	assert.Equal(t, "coupang", deal.Merchant)
+	assert.Equal(t, "무료", deal.ShippingInfo)
+	assert.Equal(t, floatPtr(0), deal.ShippingFee)
+	assert.Equal(t, "한정 수량 특가", deal.Body)
+	assert.Equal(t, []string{"https://example.com/img/1.jpg", "https://example.com/img/2.jpg"}, deal.Images)
+	assert.Equal(t, "https://example.com/thumb/1.jpg", deal.ThumbnailLink)
+
+	// Deals listed without a thumbnail take the first image, downloaded later with the others
+	unlisted := HotDeal{Id: "2", Title: "Deal", Link: "https://example.com/board/1"}
+	assert.NoError(t, crawler.EnrichDeal(context.Background(), &unlisted))
+	assert.Equal(t, "https://example.com/img/1.jpg", unlisted.ThumbnailLink)
+	assert.Empty(t, unlisted.Thumbnail)
+
+	// A crawler without detail selectors does not fetch anything
+	plain := NewUnifiedCrawler(CrawlerConfig{URL: "https://example.com", Provider: "TestProvider"}, nil)
+	plain.fetchFunc = crawler.fetchFunc
+	assert.False(t, plain.HasDetail())
+	assert.NoError(t, plain.EnrichDeal(context.Background(), &deal))
+	assert.Len(t, fetched, 2)
+
+	// A deal waiting for a slot is skipped once the crawl is abandoned
+	assert.NoError(t, crawler.DetailLimiter.Acquire(context.Background()))
+	defer crawler.DetailLimiter.Release()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	waiting := HotDeal{Id: "3", Title: "Deal", Link: "https://example.com/board/3"}
+	assert.ErrorIs(t, crawler.EnrichDeal(ctx, &waiting), context.DeadlineExceeded)
+	assert.Len(t, fetched, 2)
+}
+
+func TestEnrichDealResolvesShopLink(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/go" {
+			http.Redirect(w, req, server.URL+"/product?itemId=1&utm_source=share", http.StatusFound)
+			return
+		}
+		w.Write([]byte("product"))
+	}))
+	defer server.Close()
+
+	// The short link is served from localhost and lands on 127.0.0.1
+	shortLink := strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/go?lptag=AF1"
+
+	merchants, err := merchant.LoadData([]byte("- code: testshop\n  domains: [\"127.0.0.1\"]\n"))
+	assert.NoError(t, err)
+
+	crawler := NewUnifiedCrawler(CrawlerConfig{
+		URL:      "https://example.com",
+		Provider: "TestProvider",
+		Detail: DetailSelectors{ShopURLHandlers: []ElementHandler{func(s *goquery.Selection) string {
+			return s.Find("a.shop").AttrOr("href", "")
+		}}},
+		Merchants: merchants,
+		Redirects: redirect.NewResolver(NewMockCacheService(), time.Hour, 5, time.Second, nil),
+	}, nil)
+	crawler.fetchFunc = func(context.Context, string) (io.Reader, error) {
+		return strings.NewReader(`<a class="shop" href="` + shortLink + `">쇼핑몰</a>`), nil
+	}
+
+	deal := HotDeal{Id: "1", Title: "Deal", Link: "https://example.com/board/1"}
+	assert.NoError(t, crawler.EnrichDeal(context.Background(), &deal))
+
+	// Both the posted link and the page it lands on are published
+	assert.Equal(t, shortLink, deal.ShopURL)
+	assert.Equal(t, server.URL+"/product?itemId=1", deal.ShopFinalURL)
+	assert.Equal(t, "testshop", deal.Merchant)
+}
+
+func TestTruncateRunes(t *testing.T) {
+	assert.Equal(t, "신라면", truncateRunes("신라면", 3))
+	assert.Equal(t, "신라", truncateRunes("신라면", 2))
+}
@@ -0,0 +1,55 @@
+package crawler
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// countRegex matches a count with an optional sign and unit, e.g. "1,234", "-3", "1.2만", "3.4k"
+var countRegex = regexp.MustCompile(`([+-]?)\s*(\d[\d,]*(?:\.\d+)?)\s*(만|천|k)?`)
+
+// countMultipliers maps count units to their value
+var countMultipliers = map[string]float64{
+	"만": 10000,
+	"천": 1000,
+	"k": 1000,
+}
+
+// ParseCount parses a vote, comment or view count such as "12", "[3]", "(1,234)",
+// "1.2만" or "-2". Boards that show up and down votes as "5 - 1" yield the first number.
+// It returns nil when the text holds no count.
+func ParseCount(text string) *int {
+	m := countRegex.FindStringSubmatch(strings.ToLower(text))
+	if m == nil {
+		return nil
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(m[2], ",", ""), 64)
+	if err != nil {
+		return nil
+	}
+	if m[3] != "" {
+		value *= countMultipliers[m[3]]
+	}
+	if m[1] == "-" {
+		value = -value
+	}
+
+	count := int(value)
+	return &count
+}
+
+// extractCount reads a count from the deal row using the handlers, or the selector if there are none
+func (c *UnifiedCrawler) extractCount(s *goquery.Selection, selector string, handlers []ElementHandler) *int {
+	var text string
+	if len(handlers) > 0 {
+		text = c.applyHandlers(s, handlers)
+	} else if selector != "" {
+		text = s.Find(selector).First().Text()
+	}
+
+	return ParseCount(text)
+}
@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// TestParseCount tests parsing engagement counts
+func TestParseCount(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect *int
+	}{
+		{"12", intPtr(12)},
+		{"[3]", intPtr(3)},
+		{"(1,234)", intPtr(1234)},
+		{"추천 7", intPtr(7)},
+		{"1.2만", intPtr(12000)},
+		{"3.4K", intPtr(3400)},
+		{"-2", intPtr(-2)},
+		{"5 - 1", intPtr(5)},
+		{"0", intPtr(0)},
+		{"", nil},
+		{"없음", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			assert.Equal(t, tc.expect, ParseCount(tc.input))
+		})
+	}
+}
+
+// TestEngagementCounts tests extracting counts with selectors and handlers
+func TestEngagementCounts(t *testing.T) {
+	viewHandler := func(s *goquery.Selection) string {
+		return s.AttrOr("data-views", "")
+	}
+
+	crawler := NewUnifiedCrawler(CrawlerConfig{
+		URL:      "https://example.com",
+		BaseURL:  "https://example.com",
+		Provider: "TestProvider",
+		Selectors: Selectors{
+			DealList:     "div.deal",
+			Title:        "div.title",
+			Link:         "a.link",
+			Votes:        "span.votes",
+			Comments:     "span.comments",
+			ViewHandlers: []ElementHandler{viewHandler},
+		},
+	}, nil)
+
+	crawler.fetchFunc = func(context.Context, string) (io.Reader, error) {
+		html := `<html><body>
+			<div class="deal" data-views="1.5만">
+				<div class="title">Popular Deal</div>
+				<a class="link" href="/1">Link</a>
+				<span class="votes">42</span>
+				<span class="comments">[7]</span>
+			</div>
+			<div class="deal">
+				<div class="title">Quiet Deal</div>
+				<a class="link" href="/2">Link</a>
+			</div>
+		</body></html>`
+		return strings.NewReader(html), nil
+	}
+
+	deals, err := crawler.FetchDeals(context.Background())
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(deals)) {
+		assert.Equal(t, intPtr(42), deals[0].Votes)
+		assert.Equal(t, intPtr(7), deals[0].Comments)
+		assert.Equal(t, intPtr(15000), deals[0].Views)
+
+		assert.Nil(t, deals[1].Votes)
+		assert.Nil(t, deals[1].Comments)
+		assert.Nil(t, deals[1].Views)
+	}
+}
@@ -2,17 +2,22 @@ package crawler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"sjsage522/hotdealworker/helpers"
 	"sjsage522/hotdealworker/logger"
 	"sjsage522/hotdealworker/services/cache"
+	"sjsage522/hotdealworker/services/imagestore"
+	"sjsage522/hotdealworker/services/thumbnail"
 )
 
 // BaseCrawler provides common functionality for all crawlers
@@ -25,8 +30,44 @@ type BaseCrawler struct {
 	Provider    string
 	PriceRegex  string
 	IDExtractor IDExtractorFunc
+	// ImageReferer is sent when downloading thumbnails from sites that check it
+	ImageReferer string
+	// ImageLimiter caps concurrent thumbnail downloads; it is shared between crawlers
+	ImageLimiter Limiter
+	// ImageCacheTTL is how long an encoded thumbnail is cached by its URL (not cached when zero)
+	ImageCacheTTL time.Duration
+	// ImageMaxBytes is the largest image downloaded; larger images are rejected (no cap when zero)
+	ImageMaxBytes int64
+	// Images stores thumbnails; they are inlined in the deal as base64 when nil
+	Images imagestore.Store
+	// Thumbnails normalizes downloaded thumbnails; they are kept as downloaded when nil
+	Thumbnails *thumbnail.Normalizer
+	// Timeout bounds a whole crawl, detail pages included (no deadline when zero)
+	Timeout time.Duration
+	// Schedule sets how often the crawler runs
+	Schedule Schedule
+	// Retry sets how a failed crawl is retried
+	Retry RetryPolicy
+	// PageURL is the URL of later pages with "{page}" in place of the page number.
+	// Without it only the first page can be fetched.
+	PageURL string
+	// PageOffset is added to the page number for boards that do not number their first page 1
+	PageOffset int
+	// MaxPages is the most pages fetched while catching up, including the first
+	MaxPages int
+
+	// firstPage remembers the first page so that an unchanged board is skipped
+	// (every fetch is processed when nil)
+	firstPage *pageState
 }
 
+// ErrCoolingDown is returned while a site that rate limited the crawler is left alone
+var ErrCoolingDown = errors.New("cooling down after rate limiting")
+
+// ErrStrategiesFailed is returned when ChromeDB and FlareSolverr both failed
+// to load a page; the site is left alone for a while afterwards
+var ErrStrategiesFailed = errors.New("all fetch strategies failed")
+
 // ChromeDBStrategy represents different strategies for fetching content
 type ChromeDBStrategy struct {
 	Name        string
@@ -41,38 +82,87 @@ type ChromeDBStrategy struct {
 // ============================================================================
 
 // fetchWithCache fetches a URL with caching and rate limiting
-func (c *BaseCrawler) fetchWithCache() (io.Reader, error) {
+func (c *BaseCrawler) fetchWithCache(ctx context.Context, pageURL string) (io.Reader, error) {
 	// Check rate limiting
-	if c.CacheSvc != nil && c.CacheKey != "" {
-		_, err := c.CacheSvc.Get(c.CacheKey)
-		if err == nil {
-			return nil, fmt.Errorf("%s: %d초 동안 더 이상 요청을 보내지 않음", c.CacheKey, c.BlockTime/time.Second)
-		}
+	if err := c.coolingDown(); err != nil {
+		return nil, err
+	}
+
+	// The first page is sent the validators of its previous fetch
+	var validators helpers.Validators
+	tracked := c.firstPage != nil && pageURL == c.URL
+	if tracked {
+		validators = c.firstPage.conditional()
 	}
 
 	// Fetch the page
-	utf8Body, err := helpers.FetchWithRandomHeaders(c.URL)
+	utf8Body, issued, err := helpers.FetchConditional(ctx, pageURL, validators)
+	if errors.Is(err, helpers.ErrNotModified) {
+		if tracked {
+			c.firstPage.discard()
+		}
+		return nil, ErrUnchanged
+	}
 	if err != nil {
-		if c.CacheSvc != nil && c.CacheKey != "" && err.Error() != "" {
-			if fmt.Sprintf("%v", err)[:12] == "rate limited" {
-				// Set rate limiting cache
-				if setErr := c.CacheSvc.Set(c.CacheKey, []byte(fmt.Sprintf("%d", c.BlockTime/time.Second)), c.BlockTime); setErr != nil {
-					return nil, setErr
-				}
+		var statusErr *helpers.StatusError
+		if errors.As(err, &statusErr) && statusErr.RateLimited() {
+			if setErr := c.coolDown(statusErr.RetryAfter); setErr != nil {
+				return nil, setErr
 			}
 		}
 		return nil, err
 	}
+	if tracked {
+		c.firstPage.setValidators(issued)
+	}
 
 	return utf8Body, nil
 }
 
-// fetchWithChromeDB fetches a URL using ChromeDB first, falling back to FlareSolverr if needed
-func (c *UnifiedCrawler) fetchWithChromeDB() (io.Reader, error) {
+// coolingDown returns an ErrCoolingDown error while requests to the site are stopped
+func (c *BaseCrawler) coolingDown() error {
+	if c.CacheSvc == nil || c.CacheKey == "" {
+		return nil
+	}
+	seconds, err := c.CacheSvc.Get(c.CacheKey)
+	if err != nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %s초 동안 더 이상 요청을 보내지 않음: %w", c.CacheKey, seconds, ErrCoolingDown)
+}
+
+// coolDown stops requests to the site for as long as the server asked,
+// but never longer than BlockTime, which is also used when it did not say
+func (c *BaseCrawler) coolDown(retryAfter time.Duration) error {
+	if c.CacheSvc == nil || c.CacheKey == "" {
+		return nil
+	}
+
+	block := c.BlockTime
+	if retryAfter > 0 && retryAfter < block {
+		block = retryAfter
+	}
+	// Cache entries expire in whole seconds, and zero would never expire
+	if block < time.Second {
+		block = time.Second
+	}
+
+	seconds := int(block.Round(time.Second) / time.Second)
+	return c.CacheSvc.Set(c.CacheKey, []byte(strconv.Itoa(seconds)), time.Duration(seconds)*time.Second)
+}
+
+// fetchWithChromeDB fetches a URL using ChromeDB first, falling back to FlareSolverr if needed.
+// It gives up as soon as the context is done.
+func (c *UnifiedCrawler) fetchWithChromeDB(ctx context.Context, pageURL string) (io.Reader, error) {
+	// A site blocked after every strategy failed is left alone until the block expires
+	if err := c.coolingDown(); err != nil {
+		return nil, err
+	}
+
 	// Step 1: Try ChromeDB first
-	if err := c.checkChromeDBHealth(); err == nil {
+	if err := c.checkChromeDBHealth(ctx); err == nil {
 		logger.Debug("[%s] ChromeDB available, attempting direct fetch", c.Provider)
-		reader, err := c.fetchWithChromeDBDirect()
+		reader, err := c.fetchWithChromeDBDirect(ctx, pageURL)
 		if err == nil && reader != nil {
 			logger.Info("[%s] ChromeDB fetch successful", c.Provider)
 			return reader, nil
@@ -82,15 +172,20 @@ func (c *UnifiedCrawler) fetchWithChromeDB() (io.Reader, error) {
 		logger.Debug("[%s] ChromeDB not available: %v", c.Provider, err)
 	}
 
+	// A cancelled or expired fetch is not the site's fault, so it neither falls back nor blocks the site
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
 	// Step 2: Fallback to FlareSolverr for Cloudflare-protected sites
-	if err := c.checkFlareSolverr(); err != nil {
+	if err := c.checkFlareSolverr(ctx); err != nil {
 		logger.Error("[%s] FlareSolverr health check failed: %v", c.Provider, err)
 		return nil, fmt.Errorf("both ChromeDB and FlareSolverr unavailable")
 	}
 
 	// Try FlareSolverr as fallback
 	logger.Info("[%s] Attempting FlareSolverr as fallback", c.Provider)
-	reader, err := c.fetchWithFlareSolverr()
+	reader, err := c.fetchWithFlareSolverr(ctx, pageURL)
 	if err == nil && reader != nil {
 		logger.Info("[%s] FlareSolverr fallback successful", c.Provider)
 		return reader, nil
@@ -98,6 +193,10 @@ func (c *UnifiedCrawler) fetchWithChromeDB() (io.Reader, error) {
 
 	logger.Error("[%s] FlareSolverr fallback failed: %v", c.Provider, err)
 
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
 	// Set rate limit if all strategies failed
 	if c.CacheSvc != nil && c.CacheKey != "" {
 		blockTime := 60 * time.Second
@@ -106,11 +205,12 @@ func (c *UnifiedCrawler) fetchWithChromeDB() (io.Reader, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("all fetch strategies failed for URL: %s", c.URL)
+	return nil, fmt.Errorf("%w for URL: %s", ErrStrategiesFailed, pageURL)
 }
 
 // fetchWithChromeDBDirect performs ChromeDB fetch with all strategies
-func (c *UnifiedCrawler) fetchWithChromeDBDirect() (io.Reader, error) {
+func (c *UnifiedCrawler) fetchWithChromeDBDirect(ctx context.Context, pageURL string) (io.Reader, error) {
+	// ChromeDB is our own service, so only the target site is paced (in executeStrategy)
 	httpClient := &http.Client{Timeout: 60 * time.Second}
 
 	// ChromeDB strategies (only the working ones)
@@ -121,7 +221,7 @@ func (c *UnifiedCrawler) fetchWithChromeDBDirect() (io.Reader, error) {
 			Endpoint: "/content",
 			Method:   "POST",
 			Payload: map[string]interface{}{
-				"url": c.URL,
+				"url": pageURL,
 				"gotoOptions": map[string]interface{}{
 					"waitUntil": "networkidle0",
 					"timeout":   45000,
@@ -136,7 +236,7 @@ func (c *UnifiedCrawler) fetchWithChromeDBDirect() (io.Reader, error) {
 			Endpoint: "/content",
 			Method:   "POST",
 			Payload: map[string]interface{}{
-				"url": c.URL,
+				"url": pageURL,
 				"gotoOptions": map[string]interface{}{
 					"waitUntil": "load",
 					"timeout":   20000,
@@ -159,7 +259,7 @@ func (c *UnifiedCrawler) fetchWithChromeDBDirect() (io.Reader, error) {
 	for i, strategy := range strategies {
 		logger.Debug("[%s] Trying ChromeDB strategy %d/%d: %s", c.Provider, i+1, len(strategies), strategy.Name)
 
-		reader, err := c.executeStrategy(httpClient, strategy)
+		reader, err := c.executeStrategy(ctx, httpClient, strategy, pageURL)
 		if err == nil && reader != nil {
 			logger.Info("[%s] ChromeDB strategy %s succeeded", c.Provider, strategy.Name)
 			return reader, nil
@@ -169,11 +269,15 @@ func (c *UnifiedCrawler) fetchWithChromeDBDirect() (io.Reader, error) {
 
 		// Brief delay between attempts
 		if i < len(strategies)-1 {
-			time.Sleep(1 * time.Second)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(1 * time.Second):
+			}
 		}
 	}
 
-	return nil, fmt.Errorf("all ChromeDB strategies failed for URL: %s", c.URL)
+	return nil, fmt.Errorf("all ChromeDB strategies failed for URL: %s", pageURL)
 }
 
 // ============================================================================
@@ -181,9 +285,13 @@ func (c *UnifiedCrawler) fetchWithChromeDBDirect() (io.Reader, error) {
 // ============================================================================
 
 // checkFlareSolverr checks if FlareSolverr is available
-func (c *UnifiedCrawler) checkFlareSolverr() error {
+func (c *UnifiedCrawler) checkFlareSolverr(ctx context.Context) error {
 	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get("http://localhost:8191")
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://localhost:8191", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("FlareSolverr not available: %v", err)
 	}
@@ -192,22 +300,27 @@ func (c *UnifiedCrawler) checkFlareSolverr() error {
 }
 
 // fetchWithFlareSolverr fetches URL using FlareSolverr with dynamic proxy selection
-func (c *UnifiedCrawler) fetchWithFlareSolverr() (io.Reader, error) {
+func (c *UnifiedCrawler) fetchWithFlareSolverr(ctx context.Context, pageURL string) (io.Reader, error) {
+	// FlareSolverr is our own service, so only the target site is paced (in executeFlareSolverrRequest)
 	client := &http.Client{Timeout: 120 * time.Second}
 
 	// First try without proxy
 	payload := map[string]interface{}{
 		"cmd":        "request.get",
-		"url":        c.URL,
+		"url":        pageURL,
 		"maxTimeout": 20000,
 	}
 
-	reader, err := c.executeFlareSolverrRequest(client, payload)
+	reader, err := c.executeFlareSolverrRequest(ctx, client, payload)
 	if err == nil {
 		logger.Info("[%s] FlareSolverr succeeded without proxy", c.Provider)
 		return reader, nil
 	}
 
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
 	logger.Warn("[%s] FlareSolverr failed without proxy: %v, trying with fastest proxy", c.Provider, err)
 
 	// Try with fastest proxy
@@ -230,7 +343,7 @@ func (c *UnifiedCrawler) fetchWithFlareSolverr() (io.Reader, error) {
 	logger.Info("[%s] Trying FlareSolverr with fastest proxy: %s (latency: %v)",
 		c.Provider, proxyURL, fastestProxy.Latency)
 
-	reader, err = c.executeFlareSolverrRequest(client, payload)
+	reader, err = c.executeFlareSolverrRequest(ctx, client, payload)
 	if err == nil {
 		logger.Info("[%s] FlareSolverr succeeded with proxy %s", c.Provider, proxyURL)
 		return reader, nil
@@ -244,6 +357,9 @@ func (c *UnifiedCrawler) fetchWithFlareSolverr() (io.Reader, error) {
 		if i == 0 {
 			continue // Skip first one (already tried)
 		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 
 		proxyURL := fmt.Sprintf("socks5://%s:%d", proxy.Host, proxy.Port)
 		payload["proxy"] = map[string]interface{}{
@@ -253,7 +369,7 @@ func (c *UnifiedCrawler) fetchWithFlareSolverr() (io.Reader, error) {
 		logger.Debug("[%s] Trying proxy %d/3: %s (latency: %v)",
 			c.Provider, i+1, proxyURL, proxy.Latency)
 
-		reader, err = c.executeFlareSolverrRequest(client, payload)
+		reader, err = c.executeFlareSolverrRequest(ctx, client, payload)
 		if err == nil {
 			logger.Info("[%s] FlareSolverr succeeded with proxy %s", c.Provider, proxyURL)
 			return reader, nil
@@ -266,7 +382,14 @@ func (c *UnifiedCrawler) fetchWithFlareSolverr() (io.Reader, error) {
 }
 
 // executeFlareSolverrRequest executes a single FlareSolverr request
-func (c *UnifiedCrawler) executeFlareSolverrRequest(client *http.Client, payload map[string]interface{}) (io.Reader, error) {
+func (c *UnifiedCrawler) executeFlareSolverrRequest(ctx context.Context, client *http.Client, payload map[string]interface{}) (io.Reader, error) {
+	// FlareSolverr loads the page itself, so the site is paced here
+	if pageURL, ok := payload["url"].(string); ok {
+		if err := helpers.WaitHost(ctx, pageURL); err != nil {
+			return nil, err
+		}
+	}
+
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
@@ -279,7 +402,7 @@ func (c *UnifiedCrawler) executeFlareSolverrRequest(client *http.Client, payload
 		"Accept-Language": "en-US,en;q=0.5",
 	}
 
-	req, err := http.NewRequest("POST", "http://localhost:8191/v1", bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", "http://localhost:8191/v1", bytes.NewBuffer(data))
 	if err != nil {
 		return nil, err
 	}
@@ -331,13 +454,17 @@ func (c *UnifiedCrawler) executeFlareSolverrRequest(client *http.Client, payload
 }
 
 // checkChromeDBHealth checks if ChromeDB is available
-func (c *UnifiedCrawler) checkChromeDBHealth() error {
+func (c *UnifiedCrawler) checkChromeDBHealth(ctx context.Context) error {
 	if c.ChromeDBAddr == "" {
 		return fmt.Errorf("ChromeDB address not configured")
 	}
 
 	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(c.ChromeDBAddr + "/")
+	req, err := http.NewRequestWithContext(ctx, "GET", c.ChromeDBAddr+"/", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("ChromeDB server not reachable at %s: %v", c.ChromeDBAddr, err)
 	}
@@ -352,7 +479,12 @@ func (c *UnifiedCrawler) checkChromeDBHealth() error {
 }
 
 // executeStrategy executes a single ChromeDB strategy
-func (c *UnifiedCrawler) executeStrategy(client *http.Client, strategy ChromeDBStrategy) (io.Reader, error) {
+func (c *UnifiedCrawler) executeStrategy(ctx context.Context, client *http.Client, strategy ChromeDBStrategy, pageURL string) (io.Reader, error) {
+	// ChromeDB loads the page itself, so the site is paced here
+	if err := helpers.WaitHost(ctx, pageURL); err != nil {
+		return nil, err
+	}
+
 	var req *http.Request
 	var err error
 
@@ -362,7 +494,7 @@ func (c *UnifiedCrawler) executeStrategy(client *http.Client, strategy ChromeDBS
 			return nil, fmt.Errorf("failed to marshal payload: %v", marshalErr)
 		}
 
-		req, err = http.NewRequest("POST", c.ChromeDBAddr+strategy.Endpoint, bytes.NewBuffer(data))
+		req, err = http.NewRequestWithContext(ctx, "POST", c.ChromeDBAddr+strategy.Endpoint, bytes.NewBuffer(data))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request: %v", err)
 		}
@@ -371,9 +503,9 @@ func (c *UnifiedCrawler) executeStrategy(client *http.Client, strategy ChromeDBS
 
 	} else if strategy.Method == "GET" {
 		if strategy.Endpoint == "/scrape" {
-			req, err = http.NewRequest("GET", fmt.Sprintf("%s/scrape?url=%s", c.ChromeDBAddr, url.QueryEscape(c.URL)), nil)
+			req, err = http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/scrape?url=%s", c.ChromeDBAddr, url.QueryEscape(pageURL)), nil)
 		} else {
-			req, err = http.NewRequest("GET", c.ChromeDBAddr+strategy.Endpoint, nil)
+			req, err = http.NewRequestWithContext(ctx, "GET", c.ChromeDBAddr+strategy.Endpoint, nil)
 		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to create GET request: %v", err)
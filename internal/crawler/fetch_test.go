@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/helpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchWithCacheRateLimited(t *testing.T) {
+	retryAfter := "30"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name       string
+		retryAfter string
+		blocked    string
+	}{
+		{"server hint", "30", "30"},
+		{"hint beyond block time", "3600", "300"},
+		{"no hint", "", "300"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			retryAfter = tc.retryAfter
+			cacheSvc := NewMockCacheService()
+			crawler := BaseCrawler{CacheSvc: cacheSvc, CacheKey: "test_block", BlockTime: 300 * time.Second}
+
+			_, err := crawler.fetchWithCache(context.Background(), server.URL)
+			var statusErr *helpers.StatusError
+			assert.ErrorAs(t, err, &statusErr)
+			assert.True(t, statusErr.RateLimited())
+			assert.Equal(t, tc.blocked, string(cacheSvc.cache["test_block"]))
+
+			// The site is not asked again while it cools down
+			_, err = crawler.fetchWithCache(context.Background(), server.URL)
+			assert.ErrorIs(t, err, ErrCoolingDown)
+			assert.Contains(t, err.Error(), "test_block: "+tc.blocked+"초 동안 더 이상 요청을 보내지 않음")
+		})
+	}
+}
+
+func TestFetchWithCacheShortError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cacheSvc := NewMockCacheService()
+	crawler := BaseCrawler{CacheSvc: cacheSvc, CacheKey: "test_block", BlockTime: 300 * time.Second}
+
+	// Errors other than rate limiting do not block the site
+	_, err := crawler.fetchWithCache(context.Background(), server.URL)
+	assert.Error(t, err)
+	assert.Empty(t, cacheSvc.cache)
+
+	// Short errors such as a refused connection are not mistaken for anything else
+	_, err = crawler.fetchWithCache(context.Background(), "http://127.0.0.1:1")
+	assert.Error(t, err)
+	assert.Empty(t, cacheSvc.cache)
+}
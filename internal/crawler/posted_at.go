@@ -0,0 +1,207 @@
+package crawler
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KST is the time zone every Korean board writes its timestamps in.
+// Korea does not observe daylight saving time, so a fixed zone is exact
+// and does not depend on tzdata being installed.
+var KST = time.FixedZone("KST", 9*60*60)
+
+// futureTolerance is how far past the crawl time a parsed timestamp may lie
+// before it is assumed to belong to the previous day or year
+const futureTolerance = 10 * time.Minute
+
+var (
+	// relativeTimeRegex matches relative times such as "3분 전", "2 시간전" or "1일 전"
+	relativeTimeRegex = regexp.MustCompile(`^(\d+)\s*(초|분|시간|일|주)\s*전$`)
+
+	// postedAtLabels are prefixes some boards put before the timestamp
+	postedAtLabels = []string{"날짜", "작성일", "등록일", "작성", "등록", "시간"}
+
+	// absoluteLayouts are tried in order once date separators are normalized to "-"
+	absoluteLayouts = []string{
+		"2006-1-2 15:04:05",
+		"2006-1-2 15:04",
+		"2006-1-2",
+		"06-1-2 15:04:05",
+		"06-1-2 15:04",
+		"06-1-2",
+	}
+
+	// yearlessLayouts are dates that omit the year, e.g. "10-16" or "10.16 12:34"
+	yearlessLayouts = []string{
+		"1-2 15:04:05",
+		"1-2 15:04",
+		"1-2",
+	}
+
+	// clockLayouts are times of day without a date, e.g. "12:34"
+	clockLayouts = []string{
+		"15:04:05",
+		"15:04",
+	}
+)
+
+// NormalizePostedAt parses the posted time shown on a board into an absolute time.
+// Boards show recent posts as a time of day ("12:34") or a relative time ("3분 전")
+// and older posts as a date ("25.10.16", "2025-10-16"), all in KST. Missing parts
+// are filled in from crawledAt. The second return value is false when the text
+// is not recognized.
+func NormalizePostedAt(raw string, crawledAt time.Time) (time.Time, bool) {
+	now := crawledAt.In(KST)
+	text := cleanPostedAt(raw)
+	if text == "" {
+		return time.Time{}, false
+	}
+
+	if text == "방금" || text == "방금 전" || text == "방금전" {
+		return now, true
+	}
+
+	if m := relativeTimeRegex.FindStringSubmatch(text); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, false
+		}
+		return now.Add(-time.Duration(n) * relativeUnits[m[2]]), true
+	}
+
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t.In(KST), true
+	}
+
+	// "어제 12:34", "오늘 09:00"
+	for word, days := range dayWords {
+		if rest, ok := strings.CutPrefix(text, word); ok {
+			day := now.AddDate(0, 0, -days)
+			rest = strings.TrimSpace(rest)
+			if rest == "" {
+				return startOfDay(day), true
+			}
+			clock, ok := parseClock(rest)
+			if !ok {
+				return time.Time{}, false
+			}
+			return onDay(day, clock), true
+		}
+	}
+
+	if clock, ok := parseClock(text); ok {
+		t := onDay(now, clock)
+		// A time later than now was posted yesterday
+		if t.After(now.Add(futureTolerance)) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, true
+	}
+
+	date := normalizeDateSeparators(text)
+
+	for _, layout := range absoluteLayouts {
+		if t, err := time.ParseInLocation(layout, date, KST); err == nil {
+			return t, true
+		}
+	}
+
+	for _, layout := range yearlessLayouts {
+		if t, err := time.ParseInLocation(layout, date, KST); err == nil {
+			t = t.AddDate(now.Year(), 0, 0)
+			// A date later than now was posted last year
+			if t.After(now.Add(futureTolerance)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// SetPostedAt stores the raw posted time and its RFC3339 form on the deal.
+// PostedAt is left empty when the raw text cannot be parsed.
+func (d *HotDeal) SetPostedAt(raw string, crawledAt time.Time) {
+	d.PostedAtRaw = raw
+	d.PostedAt = ""
+	if t, ok := NormalizePostedAt(raw, crawledAt); ok {
+		d.PostedAt = t.Format(time.RFC3339)
+	}
+}
+
+var (
+	// relativeUnits maps the units of a relative time to their duration
+	relativeUnits = map[string]time.Duration{
+		"초":  time.Second,
+		"분":  time.Minute,
+		"시간": time.Hour,
+		"일":  24 * time.Hour,
+		"주":  7 * 24 * time.Hour,
+	}
+
+	// dayWords maps words for nearby days to how many days ago they are
+	dayWords = map[string]int{
+		"오늘": 0,
+		"어제": 1,
+		"그제": 2,
+	}
+)
+
+// cleanPostedAt trims labels, trailing dots and extra whitespace from the raw text
+func cleanPostedAt(raw string) string {
+	text := strings.Join(strings.Fields(raw), " ")
+	for _, label := range postedAtLabels {
+		if rest, ok := strings.CutPrefix(text, label); ok {
+			text = strings.TrimLeft(rest, " :：")
+			break
+		}
+	}
+	return strings.TrimRight(text, ". ")
+}
+
+// parseClock parses a time of day such as "12:34", "12:34:56" or "오후 3:12".
+// The returned time only carries the hour, minute and second.
+func parseClock(text string) (time.Time, bool) {
+	pm := false
+	if rest, ok := strings.CutPrefix(text, "오후"); ok {
+		pm = true
+		text = strings.TrimSpace(rest)
+	} else if rest, ok := strings.CutPrefix(text, "오전"); ok {
+		text = strings.TrimSpace(rest)
+	}
+
+	for _, layout := range clockLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			if pm && t.Hour() < 12 {
+				t = t.Add(12 * time.Hour)
+			}
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// normalizeDateSeparators rewrites "2025.10.16" and "25/10/16" as "2025-10-16" and "25-10-16"
+func normalizeDateSeparators(text string) string {
+	date, clock, _ := strings.Cut(text, " ")
+	date = strings.NewReplacer(".", "-", "/", "-").Replace(date)
+	if clock == "" {
+		return date
+	}
+	return date + " " + clock
+}
+
+// onDay returns the given time of day on the day of t, in KST
+func onDay(t, clock time.Time) time.Time {
+	y, m, d := t.In(KST).Date()
+	return time.Date(y, m, d, clock.Hour(), clock.Minute(), clock.Second(), 0, KST)
+}
+
+// startOfDay returns midnight of the day of t, in KST
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.In(KST).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, KST)
+}
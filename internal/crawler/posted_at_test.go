@@ -0,0 +1,71 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalizePostedAt tests parsing the posted times shown by the boards
+func TestNormalizePostedAt(t *testing.T) {
+	// 2025-10-16 14:00:00 KST, given in UTC to check the zone conversion
+	crawledAt := time.Date(2025, 10, 16, 5, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		input  string
+		expect string
+	}{
+		{"12:34", "2025-10-16T12:34:00+09:00"},
+		{"12:34:56", "2025-10-16T12:34:56+09:00"},
+		{"23:50", "2025-10-15T23:50:00+09:00"},
+		{"오후 1:05", "2025-10-16T13:05:00+09:00"},
+		{"3분 전", "2025-10-16T13:57:00+09:00"},
+		{"2시간 전", "2025-10-16T12:00:00+09:00"},
+		{"1일 전", "2025-10-15T14:00:00+09:00"},
+		{"방금", "2025-10-16T14:00:00+09:00"},
+		{"어제 21:10", "2025-10-15T21:10:00+09:00"},
+		{"25.10.16", "2025-10-16T00:00:00+09:00"},
+		{"25/10/15", "2025-10-15T00:00:00+09:00"},
+		{"2025.10.16.", "2025-10-16T00:00:00+09:00"},
+		{"날짜 2025.10.16", "2025-10-16T00:00:00+09:00"},
+		{"2025-10-16 12:34:56", "2025-10-16T12:34:56+09:00"},
+		{"2025-10-16T03:34:56.000Z", "2025-10-16T12:34:56+09:00"},
+		{"10-15", "2025-10-15T00:00:00+09:00"},
+		{"10/15 09:30", "2025-10-15T09:30:00+09:00"},
+		{"12.31", "2024-12-31T00:00:00+09:00"},
+		{"  \n\t 10.16  ", "2025-10-16T00:00:00+09:00"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			parsed, ok := NormalizePostedAt(tc.input, crawledAt)
+			assert.True(t, ok)
+			assert.Equal(t, tc.expect, parsed.Format(time.RFC3339))
+		})
+	}
+}
+
+// TestNormalizePostedAtUnknown tests that unrecognized text is rejected
+func TestNormalizePostedAtUnknown(t *testing.T) {
+	crawledAt := time.Date(2025, 10, 16, 14, 0, 0, 0, KST)
+
+	for _, input := range []string{"", "알 수 없음", "13.45.99", "어제 밤"} {
+		_, ok := NormalizePostedAt(input, crawledAt)
+		assert.False(t, ok, input)
+	}
+}
+
+// TestSetPostedAt tests that the raw text is kept next to the normalized time
+func TestSetPostedAt(t *testing.T) {
+	crawledAt := time.Date(2025, 10, 16, 14, 0, 0, 0, KST)
+
+	var deal HotDeal
+	deal.SetPostedAt("날짜 25.10.16", crawledAt)
+	assert.Equal(t, "2025-10-16T00:00:00+09:00", deal.PostedAt)
+	assert.Equal(t, "날짜 25.10.16", deal.PostedAtRaw)
+
+	deal.SetPostedAt("알 수 없음", crawledAt)
+	assert.Empty(t, deal.PostedAt)
+	assert.Equal(t, "알 수 없음", deal.PostedAtRaw)
+}
@@ -0,0 +1,276 @@
+package crawler
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Currencies recognized by the price parser
+const (
+	CurrencyKRW = "KRW"
+	CurrencyUSD = "USD"
+	CurrencyJPY = "JPY"
+	CurrencyEUR = "EUR"
+)
+
+// Price flags
+const (
+	// PriceFlagFree marks a deal that costs nothing
+	PriceFlagFree = "free"
+	// PriceFlagVaries marks a deal whose price depends on the option or is given as a range
+	PriceFlagVaries = "varies"
+)
+
+// PriceInfo is the structured form of a raw price string
+type PriceInfo struct {
+	Amount      *float64
+	MaxAmount   *float64
+	Currency    string
+	ShippingFee *float64
+	Flag        string
+}
+
+var (
+	// amountRegex matches a number with an optional Korean unit, e.g. "19,900", "1.5만", "3억"
+	amountRegex = regexp.MustCompile(`(\d[\d,]*(?:\.\d+)?)\s*(억|만|천)?`)
+
+	// shippingKeywordRegex matches the label that introduces a shipping fee
+	shippingKeywordRegex = regexp.MustCompile(`(배송비|택배비|배송료)\s*[:：]?`)
+
+	// shippingTermsRegex matches a shipping label that says whether the fee is
+	// included in the price rather than giving it, e.g. "배송비 포함", "택배비별도"
+	shippingTermsRegex = regexp.MustCompile(`(배송비|택배비|배송료)\s*(포함|별도)`)
+
+	// bundleRegex matches bundle offers such as "1+1" or "2 + 1", whose numbers are not prices
+	bundleRegex = regexp.MustCompile(`(^|[^\d,.])\d{1,2}\s*\+\s*\d{1,2}($|[^\d,.억만천원])`)
+
+	// yenRegex matches an amount written in yen, e.g. "1,200엔"
+	yenRegex = regexp.MustCompile(`\d\s*엔`)
+
+	// unitMultipliers maps Korean number units to their value
+	unitMultipliers = map[string]float64{
+		"억": 100000000,
+		"만": 10000,
+		"천": 1000,
+	}
+
+	freeShippingKeywords = []string{"무료배송", "무료 배송", "무배", "free shipping"}
+	freeKeywords         = []string{"무료", "공짜", "free"}
+	variesKeywords       = []string{"다양", "변동", "옵션별", "various", "varies"}
+)
+
+// ParsePrice parses a raw price string such as "19,900원", "3만원", "$12.99",
+// "₩25,000", "무료" or "10,000~20,000원" into a structured price.
+// Amounts without a currency marker are assumed to be in KRW.
+func ParsePrice(raw string) PriceInfo {
+	var info PriceInfo
+
+	text := strings.TrimSpace(raw)
+	if text == "" {
+		return info
+	}
+
+	// "배송비 포함" means there is no fee on top of the price; "배송비 별도" gives no fee
+	if m := shippingTermsRegex.FindStringSubmatch(text); m != nil {
+		if m[2] == "포함" {
+			info.ShippingFee = floatPtr(0)
+		}
+		text = shippingTermsRegex.ReplaceAllString(text, " ")
+	}
+
+	// Split off the shipping fee, e.g. "12,000원 배송비: 3,000원"
+	pricePart := text
+	if loc := shippingKeywordRegex.FindStringIndex(text); loc != nil {
+		pricePart = text[:loc[0]]
+		info.ShippingFee = ParseShippingFee(text[loc[1]:])
+	}
+	pricePart = bundleRegex.ReplaceAllString(pricePart, "$1 $2")
+
+	// Free shipping markers must not make the price itself free
+	lower := strings.ToLower(pricePart)
+	for _, keyword := range freeShippingKeywords {
+		if strings.Contains(lower, keyword) {
+			lower = strings.ReplaceAll(lower, keyword, " ")
+			if info.ShippingFee == nil {
+				info.ShippingFee = floatPtr(0)
+			}
+		}
+	}
+
+	info.Currency = detectCurrency(lower)
+	if containsAny(lower, variesKeywords) {
+		info.Flag = PriceFlagVaries
+	}
+
+	amounts, isRange := parseAmounts(lower)
+	if len(amounts) == 0 {
+		if containsAny(lower, freeKeywords) {
+			info.Amount = floatPtr(0)
+			info.Flag = PriceFlagFree
+		}
+		if info.Amount != nil && info.Currency == "" {
+			info.Currency = CurrencyKRW
+		}
+		return info
+	}
+
+	if info.Currency == "" {
+		info.Currency = CurrencyKRW
+	}
+
+	info.Amount = floatPtr(amounts[0])
+	if isRange {
+		low, high := amounts[0], amounts[1]
+		if low > high {
+			low, high = high, low
+		}
+		info.Amount = floatPtr(low)
+		info.MaxAmount = floatPtr(high)
+		info.Flag = PriceFlagVaries
+	} else if strings.ContainsAny(lower, "~～") || strings.Contains(lower, "부터") {
+		// An open range such as "9,900원~" only gives the lowest price
+		info.Flag = PriceFlagVaries
+	}
+
+	if *info.Amount == 0 && info.MaxAmount == nil {
+		info.Flag = PriceFlagFree
+	}
+
+	return info
+}
+
+// SetPriceInfo copies a parsed price into the deal's typed price fields
+func (d *HotDeal) SetPriceInfo(info PriceInfo) {
+	d.PriceAmount = info.Amount
+	d.PriceMax = info.MaxAmount
+	d.Currency = info.Currency
+	d.ShippingFee = info.ShippingFee
+	d.PriceFlag = info.Flag
+}
+
+// ParseShippingFee parses text that only describes a shipping fee, e.g. "무료" or "3,000원".
+// It returns nil when the fee is unknown.
+func ParseShippingFee(text string) *float64 {
+	lower := strings.ToLower(strings.TrimSpace(text))
+	if lower == "" {
+		return nil
+	}
+
+	if containsAny(lower, freeKeywords) || containsAny(lower, freeShippingKeywords) {
+		return floatPtr(0)
+	}
+
+	if amounts, _ := parseAmounts(lower); len(amounts) > 0 {
+		return floatPtr(amounts[0])
+	}
+	return nil
+}
+
+// ExtractShippingFee finds a shipping fee mentioned in a longer text such as a title,
+// e.g. "[쿠팡] 신라면 (19,900원/무배)". It returns nil when no fee is mentioned.
+func ExtractShippingFee(text string) *float64 {
+	lower := strings.ToLower(text)
+	if containsAny(lower, freeShippingKeywords) {
+		return floatPtr(0)
+	}
+	if m := shippingTermsRegex.FindStringSubmatch(lower); m != nil {
+		if m[2] == "포함" {
+			return floatPtr(0)
+		}
+		return nil
+	}
+
+	loc := shippingKeywordRegex.FindStringIndex(lower)
+	if loc == nil {
+		return nil
+	}
+
+	// Only look at the text right after the label
+	rest := lower[loc[1]:]
+	if end := strings.IndexAny(rest, ")]/|"); end != -1 {
+		rest = rest[:end]
+	}
+	return ParseShippingFee(rest)
+}
+
+// parseAmounts returns every amount in the text, merging compound Korean
+// amounts such as "3만 5천" into one. isRange reports whether the first two
+// amounts are separated by a range marker; the unit of the upper bound also
+// applies to a bare lower bound, as in "2-3만원".
+func parseAmounts(text string) (amounts []float64, isRange bool) {
+	matches := amountRegex.FindAllStringSubmatchIndex(text, -1)
+
+	prevEnd := -1
+	prevUnit := ""
+	var units []string
+	for _, m := range matches {
+		value, err := strconv.ParseFloat(strings.ReplaceAll(text[m[2]:m[3]], ",", ""), 64)
+		if err != nil {
+			continue
+		}
+
+		unit := ""
+		if m[4] != -1 {
+			unit = text[m[4]:m[5]]
+			value *= unitMultipliers[unit]
+		}
+
+		// "3만 5천", "3만5000": add to the previous amount when it ended with a larger unit
+		gap := ""
+		if prevEnd != -1 {
+			gap = text[prevEnd:m[0]]
+		}
+		if prevUnit != "" && len(amounts) > 0 && strings.TrimSpace(gap) == "" &&
+			(unit == "" || unitMultipliers[unit] < unitMultipliers[prevUnit]) {
+			amounts[len(amounts)-1] += value
+		} else {
+			if len(amounts) == 1 && strings.ContainsAny(gap, "~～-") {
+				isRange = true
+			}
+			amounts = append(amounts, value)
+			units = append(units, unit)
+		}
+
+		prevEnd = m[1]
+		prevUnit = unit
+	}
+
+	if isRange && units[0] == "" && units[1] != "" {
+		if lower := amounts[0] * unitMultipliers[units[1]]; lower <= amounts[1] {
+			amounts[0] = lower
+		}
+	}
+	return amounts, isRange
+}
+
+// detectCurrency returns the currency marked in the text, or an empty string if none
+func detectCurrency(text string) string {
+	switch {
+	case strings.ContainsAny(text, "₩￦") || strings.Contains(text, "원") || strings.Contains(text, "krw"):
+		return CurrencyKRW
+	case strings.Contains(text, "$") || strings.Contains(text, "usd") || strings.Contains(text, "달러"):
+		return CurrencyUSD
+	case strings.ContainsAny(text, "€") || strings.Contains(text, "eur") || strings.Contains(text, "유로"):
+		return CurrencyEUR
+	case strings.ContainsAny(text, "¥￥") || strings.Contains(text, "jpy") || yenRegex.MatchString(text):
+		return CurrencyJPY
+	default:
+		return ""
+	}
+}
+
+// containsAny reports whether the text contains any of the keywords
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// floatPtr returns a pointer to the given value
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParsePrice tests parsing raw price strings into structured prices
+func TestParsePrice(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectAmount   *float64
+		expectMax      *float64
+		expectCurrency string
+		expectShipping *float64
+		expectFlag     string
+	}{
+		{"19,900원", floatPtr(19900), nil, CurrencyKRW, nil, ""},
+		{"3만원", floatPtr(30000), nil, CurrencyKRW, nil, ""},
+		{"1.5만원", floatPtr(15000), nil, CurrencyKRW, nil, ""},
+		{"3만 5천원", floatPtr(35000), nil, CurrencyKRW, nil, ""},
+		{"12만9000원", floatPtr(129000), nil, CurrencyKRW, nil, ""},
+		{"$12.99", floatPtr(12.99), nil, CurrencyUSD, nil, ""},
+		{"₩25,000", floatPtr(25000), nil, CurrencyKRW, nil, ""},
+		{"￦ 25,000 (KRW)", floatPtr(25000), nil, CurrencyKRW, nil, ""},
+		{"€9.99", floatPtr(9.99), nil, CurrencyEUR, nil, ""},
+		{"1,200엔", floatPtr(1200), nil, CurrencyJPY, nil, ""},
+		{"¥3,980", floatPtr(3980), nil, CurrencyJPY, nil, ""},
+		{"무료", floatPtr(0), nil, CurrencyKRW, nil, PriceFlagFree},
+		{"0원", floatPtr(0), nil, CurrencyKRW, nil, PriceFlagFree},
+		{"10,000~20,000원", floatPtr(10000), floatPtr(20000), CurrencyKRW, nil, PriceFlagVaries},
+		{"$10 - $20", floatPtr(10), floatPtr(20), CurrencyUSD, nil, PriceFlagVaries},
+		{"가격다양", nil, nil, "", nil, PriceFlagVaries},
+		{"12,000원 배송비: 무료", floatPtr(12000), nil, CurrencyKRW, floatPtr(0), ""},
+		{"12,000원 배송비: 3,000원", floatPtr(12000), nil, CurrencyKRW, floatPtr(3000), ""},
+		{"19,900원/무배", floatPtr(19900), nil, CurrencyKRW, floatPtr(0), ""},
+		{"19,900원(카드할인시 17,000원)", floatPtr(19900), nil, CurrencyKRW, nil, ""},
+		{"2-3만원", floatPtr(20000), floatPtr(30000), CurrencyKRW, nil, PriceFlagVaries},
+		{"9,900~1.5만원", floatPtr(9900), floatPtr(15000), CurrencyKRW, nil, PriceFlagVaries},
+		{"9,900원~", floatPtr(9900), nil, CurrencyKRW, nil, PriceFlagVaries},
+		{"1+1 9,900원", floatPtr(9900), nil, CurrencyKRW, nil, ""},
+		{"9,900원 (2+1)", floatPtr(9900), nil, CurrencyKRW, nil, ""},
+		{"배송비 포함 19,900원", floatPtr(19900), nil, CurrencyKRW, floatPtr(0), ""},
+		{"19,900원 (배송비별도)", floatPtr(19900), nil, CurrencyKRW, nil, ""},
+		{"", nil, nil, "", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		info := ParsePrice(tc.input)
+		assert.Equal(t, tc.expectAmount, info.Amount, "amount: "+tc.input)
+		assert.Equal(t, tc.expectMax, info.MaxAmount, "max amount: "+tc.input)
+		assert.Equal(t, tc.expectCurrency, info.Currency, "currency: "+tc.input)
+		assert.Equal(t, tc.expectShipping, info.ShippingFee, "shipping: "+tc.input)
+		assert.Equal(t, tc.expectFlag, info.Flag, "flag: "+tc.input)
+	}
+}
+
+// TestExtractShippingFee tests finding shipping fees in titles
+func TestExtractShippingFee(t *testing.T) {
+	assert.Equal(t, floatPtr(0), ExtractShippingFee("[쿠팡] 신라면 40봉 (19,900원/무배)"))
+	assert.Equal(t, floatPtr(0), ExtractShippingFee("[11번가] 생수 2L (9,900원/무료배송)"))
+	assert.Equal(t, floatPtr(2500), ExtractShippingFee("[G마켓] 휴지 (12,900원/배송비 2,500원)"))
+	assert.Equal(t, floatPtr(0), ExtractShippingFee("[네이버] 샴푸 (배송비 포함 19,900원)"))
+	assert.Nil(t, ExtractShippingFee("[옥션] 세제 (9,900원/배송비 별도)"))
+	assert.Nil(t, ExtractShippingFee("[쿠팡] 신라면 40봉 (19,900원)"))
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"sort"
 	"strconv"
@@ -13,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"sjsage522/hotdealworker/helpers"
 	"sjsage522/hotdealworker/logger"
 )
 
@@ -47,9 +49,7 @@ func NewProxyManager() *ProxyManager {
 func (pm *ProxyManager) fetchProxiesFromSpysOne() ([]ProxyInfo, error) {
 	logger.Debug("Fetching SOCKS5 proxies from spys.me")
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	client := helpers.NewClient(30 * time.Second)
 
 	// Create request with proper headers
 	req, err := http.NewRequest("GET", "https://spys.me/socks.txt", nil)
@@ -150,31 +150,38 @@ func (pm *ProxyManager) testProxyLatency(proxy *ProxyInfo) {
 	}
 	defer conn.Close()
 
-	// Additional HTTP test through proxy with shorter timeout
+	// Additional HTTP test through proxy with shorter timeout. The host limiter
+	// may hold the request first, so only the exchange itself is timed
 	proxyURL := fmt.Sprintf("socks5://%s:%d", proxy.Host, proxy.Port)
 	transport := &http.Transport{
 		Proxy: func(*http.Request) (*url.URL, error) {
 			return url.Parse(proxyURL)
 		},
+		DialContext:           (&net.Dialer{Timeout: 5 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Second,
 	}
 
 	client := &http.Client{
-		Transport: transport,
-		Timeout:   5 * time.Second,
+		Transport: helpers.LimitTransport(transport),
+		Timeout:   30 * time.Second,
 	}
 
 	// Test with a simple HTTP request
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", "http://httpbin.org/ip", nil)
+	var testStart time.Time
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) { testStart = time.Now() },
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), "GET", "http://httpbin.org/ip", nil)
 	if err != nil {
 		proxy.Working = false
 		proxy.Latency = time.Hour
 		return
 	}
 
-	testStart := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
 		proxy.Working = false
@@ -0,0 +1,422 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sjsage522/hotdealworker/config"
+	"sjsage522/hotdealworker/internal/merchant"
+	"sjsage522/hotdealworker/logger"
+	"sjsage522/hotdealworker/services/cache"
+	"sjsage522/hotdealworker/services/imagestore"
+	"sjsage522/hotdealworker/services/redirect"
+	"sjsage522/hotdealworker/services/thumbnail"
+)
+
+// imageStoreTimeout bounds each request to an S3-compatible image store
+const imageStoreTimeout = 10 * time.Second
+
+// SiteReloader keeps the crawlers in sync with the site definitions and merchant rules.
+// On reload it re-reads the sites directory, rejects definitions that fail to
+// validate while keeping their previous version, and rebuilds only the
+// crawlers whose definition changed. Changed merchant rules rebuild every crawler.
+type SiteReloader struct {
+	cfg      *config.Config
+	cacheSvc cache.CacheService
+	log      *logger.Logger
+	// detailLimiter and imageLimiter are shared by every crawler so the caps hold across sites
+	detailLimiter Limiter
+	imageLimiter  Limiter
+	redirects     *redirect.Resolver
+	images        imagestore.Store
+	thumbnails    *thumbnail.Normalizer
+
+	mu        sync.Mutex
+	builtIn   map[string]siteFile // built-in definitions by name
+	overrides map[string]siteFile // last valid definition in the sites directory by file name
+	crawlers  map[string]Crawler  // current crawlers by definition name
+	sources   map[string][]byte   // definition each crawler was built from
+	dirState  string              // listing of the sites directory and merchants file at the last reload
+
+	merchants     *merchant.Resolver
+	merchantsData []byte // merchants file the resolver was built from
+}
+
+// NewSiteReloader loads the site definitions and builds the initial crawlers.
+// Unlike a reload, any invalid definition fails the initial load.
+func NewSiteReloader(cfg *config.Config, cacheSvc cache.CacheService) (*SiteReloader, error) {
+	r := &SiteReloader{
+		cfg:       cfg,
+		cacheSvc:  cacheSvc,
+		log:       logger.ForComponent("site_reloader"),
+		builtIn:   make(map[string]siteFile),
+		overrides: make(map[string]siteFile),
+		crawlers:  make(map[string]Crawler),
+		sources:   make(map[string][]byte),
+	}
+	if cfg.DetailConcurrency > 0 {
+		r.detailLimiter = NewLimiter(cfg.DetailConcurrency)
+	}
+	if cfg.ImageConcurrency > 0 {
+		r.imageLimiter = NewLimiter(cfg.ImageConcurrency)
+	}
+	if cfg.RedirectMaxHops > 0 {
+		r.redirects = redirect.NewResolver(cacheSvc, cfg.RedirectCacheTTL, cfg.RedirectMaxHops, cfg.RedirectTimeout, cfg.RedirectHostTimeouts)
+	}
+	r.images = newImageStore(cfg)
+	r.thumbnails = thumbnail.NewNormalizer(thumbnail.Options{
+		MaxWidth:  cfg.ThumbnailMaxWidth,
+		MaxHeight: cfg.ThumbnailMaxHeight,
+		MinSize:   cfg.ThumbnailMinSize,
+		Quality:   cfg.ThumbnailQuality,
+		MaxBytes:  cfg.ThumbnailMaxBytes,
+	})
+
+	files, err := readSiteFiles(embeddedSites, "sites")
+	if err != nil {
+		return nil, fmt.Errorf("built-in site definitions: %w", err)
+	}
+	for _, file := range files {
+		if file.err != nil {
+			return nil, fmt.Errorf("built-in site definitions: %s: %w", file.path, file.err)
+		}
+		r.builtIn[file.def.Name] = file
+	}
+
+	r.dirState = r.readDirState()
+	if _, err := r.loadMerchants(); err != nil {
+		return nil, fmt.Errorf("merchant rules in %s: %w", cfg.MerchantsFile, err)
+	}
+
+	if cfg.SitesDir != "" {
+		files, err := readSiteFiles(os.DirFS(cfg.SitesDir), ".")
+		if err != nil {
+			return nil, fmt.Errorf("site definitions in %s: %w", cfg.SitesDir, err)
+		}
+		for _, file := range files {
+			if file.err != nil {
+				return nil, fmt.Errorf("site definitions in %s: %s: %w", cfg.SitesDir, file.path, file.err)
+			}
+			r.overrides[file.path] = file
+		}
+	}
+
+	r.rebuild()
+	return r, nil
+}
+
+// retryPolicy fills the fields the site definition leaves unset with the
+// configured defaults
+func (r *SiteReloader) retryPolicy(policy RetryPolicy) RetryPolicy {
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = r.cfg.RetryMaxAttempts
+	}
+	if policy.BaseBackoff == 0 {
+		policy.BaseBackoff = r.cfg.RetryBaseBackoff
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = r.cfg.RetryMaxBackoff
+	}
+	if policy.MaxBackoff < policy.BaseBackoff {
+		policy.MaxBackoff = policy.BaseBackoff
+	}
+	if policy.Jitter == 0 {
+		policy.Jitter = r.cfg.RetryJitter
+	}
+	return policy
+}
+
+// newImageStore creates the image store chosen in the config.
+// Thumbnails are inlined in the deals when it returns nil.
+func newImageStore(cfg *config.Config) imagestore.Store {
+	switch cfg.ImageStore {
+	case "local":
+		return imagestore.NewLocalStore(cfg.ImageStoreDir, cfg.ImageBaseURL)
+	case "s3":
+		return imagestore.NewS3Store(imagestore.S3Options{
+			Endpoint:  cfg.ImageS3Endpoint,
+			Bucket:    cfg.ImageS3Bucket,
+			Region:    cfg.ImageS3Region,
+			AccessKey: cfg.ImageS3AccessKey,
+			SecretKey: cfg.ImageS3SecretKey,
+			BaseURL:   cfg.ImageBaseURL,
+			Timeout:   imageStoreTimeout,
+		})
+	default:
+		return nil
+	}
+}
+
+// Crawlers returns the current crawlers sorted by definition name
+func (r *SiteReloader) Crawlers() []Crawler {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sortedCrawlers()
+}
+
+// Reload re-reads the sites directory and merchant rules and rebuilds the
+// crawlers whose definition changed.
+// It returns the full set of crawlers and whether anything changed.
+func (r *SiteReloader) Reload() ([]Crawler, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dirState = r.readDirState()
+	if r.cfg.SitesDir != "" {
+		r.reloadOverrides()
+	}
+
+	merchantsChanged, err := r.loadMerchants()
+	if err != nil {
+		r.log.Error().
+			Err(err).
+			Str("file", r.cfg.MerchantsFile).
+			Msg("Rejected merchant rules, keeping the current ones")
+	} else if merchantsChanged {
+		// Forget every source so that all crawlers pick up the new rules
+		r.sources = make(map[string][]byte)
+	}
+
+	changed := r.rebuild()
+	return r.sortedCrawlers(), changed
+}
+
+// Watch reloads the site definitions whenever the sites directory or the
+// merchants file changes (checked every interval) or a signal arrives on hup,
+// and hands the new crawlers to apply. It blocks until the context is cancelled.
+func (r *SiteReloader) Watch(ctx context.Context, interval time.Duration, hup <-chan os.Signal, apply func([]Crawler)) {
+	var tick <-chan time.Time
+	if interval > 0 && (r.cfg.SitesDir != "" || r.cfg.MerchantsFile != "") {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-hup:
+			r.log.Info().
+				Str("signal", sig.String()).
+				Msg("Reloading site definitions")
+			r.reloadAndApply(apply)
+		case <-tick:
+			if r.dirChanged() {
+				r.log.Info().
+					Str("dir", r.cfg.SitesDir).
+					Msg("Site definitions changed, reloading")
+				r.reloadAndApply(apply)
+			}
+		}
+	}
+}
+
+// reloadAndApply reloads and hands the crawlers to apply if anything changed
+func (r *SiteReloader) reloadAndApply(apply func([]Crawler)) {
+	crawlers, changed := r.Reload()
+	if !changed {
+		r.log.Debug().Msg("Site definitions unchanged")
+		return
+	}
+	apply(crawlers)
+}
+
+// reloadOverrides re-reads the sites directory. Files that fail to validate
+// keep their previous definition; files that were removed fall back to the
+// built-in definition.
+func (r *SiteReloader) reloadOverrides() {
+	files, err := readSiteFiles(os.DirFS(r.cfg.SitesDir), ".")
+	if err != nil {
+		r.log.Error().
+			Err(err).
+			Str("dir", r.cfg.SitesDir).
+			Msg("Failed to read site definitions, keeping the current ones")
+		return
+	}
+
+	overrides := make(map[string]siteFile, len(files))
+	for _, file := range files {
+		if file.err != nil {
+			previous, ok := r.overrides[file.path]
+			r.log.Error().
+				Err(file.err).
+				Str("file", file.path).
+				Bool("kept_previous", ok).
+				Msg("Rejected site definition")
+			if ok {
+				overrides[file.path] = previous
+			}
+			continue
+		}
+		overrides[file.path] = file
+	}
+	r.overrides = overrides
+}
+
+// loadMerchants reads the merchants file and replaces the resolver if the file changed.
+// It reports whether the resolver was replaced.
+func (r *SiteReloader) loadMerchants() (bool, error) {
+	if r.cfg.MerchantsFile == "" {
+		if r.merchants != nil {
+			return false, nil
+		}
+		r.merchants = merchant.Default()
+		return true, nil
+	}
+
+	data, err := os.ReadFile(r.cfg.MerchantsFile)
+	if err != nil {
+		return false, err
+	}
+	if r.merchants != nil && bytes.Equal(data, r.merchantsData) {
+		return false, nil
+	}
+
+	resolver, err := merchant.LoadData(data)
+	if err != nil {
+		return false, err
+	}
+	r.merchants = resolver
+	r.merchantsData = data
+	return true, nil
+}
+
+// rebuild builds crawlers for new or changed definitions and drops the ones
+// that were removed or disabled. It reports whether anything changed.
+func (r *SiteReloader) rebuild() bool {
+	files := make(map[string]siteFile, len(r.builtIn))
+	for name, file := range r.builtIn {
+		files[name] = file
+	}
+
+	paths := make([]string, 0, len(r.overrides))
+	for path := range r.overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		file := r.overrides[path]
+		files[file.def.Name] = file
+	}
+
+	changed := false
+
+	for name := range r.crawlers {
+		if _, ok := files[name]; !ok || !r.enabled(name) {
+			delete(r.crawlers, name)
+			delete(r.sources, name)
+			changed = true
+			r.log.Info().
+				Str("crawler", name).
+				Msg("Crawler removed")
+		}
+	}
+
+	for name, file := range files {
+		if !r.enabled(name) {
+			continue
+		}
+		if _, ok := r.crawlers[name]; ok && bytes.Equal(r.sources[name], file.data) {
+			continue
+		}
+
+		def := file.def
+		crawlerCfg := def.CrawlerConfig(r.cfg.Crawlers[name].URL, r.cfg.ChromeDBAddr)
+		if crawlerCfg.MaxPages == 0 {
+			crawlerCfg.MaxPages = r.cfg.CatchUpMaxPages
+		}
+		if crawlerCfg.Timeout == 0 {
+			crawlerCfg.Timeout = r.cfg.CrawlTimeout
+		}
+		crawlerCfg.Retry = r.retryPolicy(crawlerCfg.Retry)
+		// DETAIL_CONCURRENCY=0 turns detail pages off
+		if r.detailLimiter == nil {
+			crawlerCfg.Detail = DetailSelectors{}
+		}
+		crawlerCfg.DetailLimiter = r.detailLimiter
+		crawlerCfg.ImageLimiter = r.imageLimiter
+		crawlerCfg.ImageCacheTTL = r.cfg.ImageCacheTTL
+		crawlerCfg.ImageMaxBytes = int64(r.cfg.ImageMaxBytes)
+		crawlerCfg.Images = r.images
+		crawlerCfg.Thumbnails = r.thumbnails
+		crawlerCfg.Merchants = r.merchants
+		crawlerCfg.Redirects = r.redirects
+		crawler := NewUnifiedCrawler(crawlerCfg, r.cacheSvc)
+		r.crawlers[name] = crawler
+		r.sources[name] = file.data
+		changed = true
+
+		r.log.Info().
+			Str("crawler", name).
+			Str("url", crawler.URL).
+			Msg("Crawler created")
+	}
+
+	return changed
+}
+
+// enabled reports whether the configuration enables the crawler.
+// Sites unknown to the configuration are enabled.
+func (r *SiteReloader) enabled(name string) bool {
+	crawlerCfg, known := r.cfg.Crawlers[name]
+	return !known || crawlerCfg.Enabled
+}
+
+// sortedCrawlers returns the crawlers sorted by definition name
+func (r *SiteReloader) sortedCrawlers() []Crawler {
+	names := make([]string, 0, len(r.crawlers))
+	for name := range r.crawlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	crawlers := make([]Crawler, 0, len(names))
+	for _, name := range names {
+		crawlers = append(crawlers, r.crawlers[name])
+	}
+	return crawlers
+}
+
+// dirChanged reports whether the sites directory changed since the last reload
+func (r *SiteReloader) dirChanged() bool {
+	state := r.readDirState()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return state != r.dirState
+}
+
+// readDirState lists the definition files and the merchants file with their
+// size and modification time
+func (r *SiteReloader) readDirState() string {
+	var state strings.Builder
+
+	if r.cfg.SitesDir != "" {
+		entries, _ := os.ReadDir(r.cfg.SitesDir)
+		for _, entry := range entries {
+			if entry.IsDir() || !isSiteFile(entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(&state, "%s:%d:%d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+
+	if r.cfg.MerchantsFile != "" {
+		if info, err := os.Stat(r.cfg.MerchantsFile); err == nil {
+			fmt.Fprintf(&state, "%s:%d:%d\n", r.cfg.MerchantsFile, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+
+	return state.String()
+}
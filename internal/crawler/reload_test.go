@@ -0,0 +1,204 @@
+package crawler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// findCrawler returns the crawler of the given provider
+func findCrawler(crawlers []Crawler, provider string) Crawler {
+	for _, c := range crawlers {
+		if c.GetProvider() == provider {
+			return c
+		}
+	}
+	return nil
+}
+
+func writeSite(t *testing.T, dir, file, dealList string) {
+	data := "name: ppom\nprovider: Ppom\nsite_url: https://www.ppomppu.co.kr\nselectors: {deal_list: " + dealList + ", link: a, title: a}\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(data), 0o644))
+}
+
+func TestSiteReloader(t *testing.T) {
+	dir := t.TempDir()
+	writeSite(t, dir, "ppom.yaml", "tr.v1")
+
+	cfg := config.LoadConfig()
+	cfg.SitesDir = dir
+	reloader, err := NewSiteReloader(&cfg, nil)
+	assert.NoError(t, err)
+
+	crawlers := reloader.Crawlers()
+	ppom := findCrawler(crawlers, ProviderPpom)
+	fmkorea := findCrawler(crawlers, ProviderFMKorea)
+	assert.Equal(t, "tr.v1", ppom.(*UnifiedCrawler).Selectors.DealList)
+
+	// Nothing changed
+	crawlers, changed := reloader.Reload()
+	assert.False(t, changed)
+	assert.Same(t, ppom, findCrawler(crawlers, ProviderPpom))
+
+	// Only the edited definition is rebuilt
+	writeSite(t, dir, "ppom.yaml", "tr.v2")
+	crawlers, changed = reloader.Reload()
+	assert.True(t, changed)
+	assert.Equal(t, "tr.v2", findCrawler(crawlers, ProviderPpom).(*UnifiedCrawler).Selectors.DealList)
+	assert.Same(t, fmkorea, findCrawler(crawlers, ProviderFMKorea))
+	ppom = findCrawler(crawlers, ProviderPpom)
+
+	// A broken definition is rejected and the previous one kept
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ppom.yaml"), []byte("name: ppom\nselectors: ["), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("name: broken\n"), 0o644))
+	crawlers, changed = reloader.Reload()
+	assert.False(t, changed)
+	assert.Same(t, ppom, findCrawler(crawlers, ProviderPpom))
+
+	// Removing the override restores the built-in definition
+	assert.NoError(t, os.Remove(filepath.Join(dir, "ppom.yaml")))
+	crawlers, changed = reloader.Reload()
+	assert.True(t, changed)
+	assert.Equal(t, "tr.baseList.bbs_new1", findCrawler(crawlers, ProviderPpom).(*UnifiedCrawler).Selectors.DealList)
+}
+
+func TestSiteReloaderBuiltInSites(t *testing.T) {
+	cfg := config.LoadConfig()
+	reloader, err := NewSiteReloader(&cfg, nil)
+	assert.NoError(t, err)
+
+	// Every built-in site has a crawler entry in the config and gets a crawler
+	defs := builtInSites(t)
+	assert.Len(t, defs, len(cfg.Crawlers))
+	for name := range defs {
+		_, ok := cfg.Crawlers[name]
+		assert.True(t, ok, name)
+	}
+	assert.Len(t, reloader.Crawlers(), len(defs))
+}
+
+func TestSiteReloaderAddedSite(t *testing.T) {
+	dir := t.TempDir()
+	added := `{"name": "newsite", "provider": "NewSite", "site_url": "https://new.example.com", "selectors": {"deal_list": "li", "link": "a", "title": "a"}}`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "newsite.json"), []byte(added), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o644))
+
+	cfg := config.LoadConfig()
+	cfg.SitesDir = dir
+	reloader, err := NewSiteReloader(&cfg, nil)
+	assert.NoError(t, err)
+
+	// Sites unknown to the config are crawled next to the built-in ones
+	crawlers := reloader.Crawlers()
+	assert.Len(t, crawlers, len(cfg.Crawlers)+1)
+	if newsite := findCrawler(crawlers, "NewSite"); assert.NotNil(t, newsite) {
+		assert.Equal(t, "li", newsite.(*UnifiedCrawler).Selectors.DealList)
+	}
+}
+
+func TestSiteReloaderRetryPolicy(t *testing.T) {
+	cfg := config.LoadConfig()
+	reloader, err := NewSiteReloader(&cfg, nil)
+	assert.NoError(t, err)
+
+	// Sites without a retry rule use the configured policy
+	clien := findCrawler(reloader.Crawlers(), ProviderClien).(*UnifiedCrawler)
+	assert.Equal(t, RetryPolicy{MaxAttempts: 3, BaseBackoff: 2 * time.Second, MaxBackoff: 30 * time.Second, Jitter: time.Second}, clien.CrawlRetry())
+
+	// A site's rule overrides only the fields it sets
+	fmkorea := findCrawler(reloader.Crawlers(), ProviderFMKorea).(*UnifiedCrawler)
+	assert.Equal(t, RetryPolicy{MaxAttempts: 2, BaseBackoff: 10 * time.Second, MaxBackoff: 30 * time.Second, Jitter: time.Second}, fmkorea.CrawlRetry())
+}
+
+func TestSiteReloaderMerchants(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "merchants.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("- code: oliveyoung\n  aliases: [올리브영]\n"), 0o644))
+
+	cfg := config.LoadConfig()
+	cfg.MerchantsFile = file
+	reloader, err := NewSiteReloader(&cfg, nil)
+	assert.NoError(t, err)
+
+	ppom := findCrawler(reloader.Crawlers(), ProviderPpom).(*UnifiedCrawler)
+	assert.Equal(t, "oliveyoung", ppom.Merchants.FromTitle("[올리브영] 선크림"))
+	assert.Equal(t, "coupang", ppom.Merchants.FromTitle("[쿠팡] 신라면"))
+
+	// Broken rules are rejected and the current ones kept
+	assert.NoError(t, os.WriteFile(file, []byte("- code: [x"), 0o644))
+	_, changed := reloader.Reload()
+	assert.False(t, changed)
+
+	// New rules rebuild every crawler
+	assert.NoError(t, os.WriteFile(file, []byte("- code: musinsa\n  aliases: [무신사, 무탠다드]\n"), 0o644))
+	crawlers, changed := reloader.Reload()
+	assert.True(t, changed)
+	for _, c := range crawlers {
+		assert.Equal(t, "musinsa", c.(*UnifiedCrawler).Merchants.FromTitle("[무탠다드] 티셔츠"))
+	}
+}
+
+func TestSiteReloaderDisabledCrawlers(t *testing.T) {
+	cfg := config.LoadConfig()
+	cfg.Crawlers["ppom"] = config.CrawlerConfig{Enabled: false, URL: cfg.PpomURL}
+
+	reloader, err := NewSiteReloader(&cfg, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, findCrawler(reloader.Crawlers(), ProviderPpom))
+	assert.NotNil(t, findCrawler(reloader.Crawlers(), ProviderFMKorea))
+}
+
+func TestSiteReloaderInvalidInitialLoad(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("name: broken\n"), 0o644))
+
+	cfg := config.LoadConfig()
+	cfg.SitesDir = dir
+	_, err := NewSiteReloader(&cfg, nil)
+	assert.Error(t, err)
+}
+
+func TestSiteReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeSite(t, dir, "ppom.yaml", "tr.v1")
+
+	cfg := config.LoadConfig()
+	cfg.SitesDir = dir
+	reloader, err := NewSiteReloader(&cfg, nil)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	applied := make(chan []Crawler, 1)
+	hup := make(chan os.Signal, 1)
+	go reloader.Watch(ctx, 10*time.Millisecond, hup, func(crawlers []Crawler) {
+		applied <- crawlers
+	})
+
+	// File changes are picked up by polling
+	time.Sleep(20 * time.Millisecond)
+	writeSite(t, dir, "ppom.yaml", "tr.polled")
+	select {
+	case crawlers := <-applied:
+		assert.Equal(t, "tr.polled", findCrawler(crawlers, ProviderPpom).(*UnifiedCrawler).Selectors.DealList)
+	case <-time.After(2 * time.Second):
+		t.Fatal("polling did not reload the definitions")
+	}
+
+	// A signal reloads even when the modification time did not move
+	cfg.Crawlers["fmkorea"] = config.CrawlerConfig{Enabled: false}
+	hup <- syscall.SIGHUP
+	select {
+	case crawlers := <-applied:
+		assert.Nil(t, findCrawler(crawlers, ProviderFMKorea))
+	case <-time.After(2 * time.Second):
+		t.Fatal("signal did not reload the definitions")
+	}
+}
@@ -0,0 +1,29 @@
+package crawler
+
+import "time"
+
+// RetryPolicy sets how a crawl that fails with a retryable error is tried
+// again within the same run
+type RetryPolicy struct {
+	// MaxAttempts is how many times the crawl is tried, the first included;
+	// the crawl is not retried when it is at most one
+	MaxAttempts int
+	// BaseBackoff is the wait before the first retry; it doubles with each
+	// retry up to MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Jitter is the most time added at random to each wait
+	Jitter time.Duration
+}
+
+// Backoff returns the wait before the given retry, starting at 1, without jitter
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.BaseBackoff
+	for i := 1; i < retry && (p.MaxBackoff == 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
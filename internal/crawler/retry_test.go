@@ -0,0 +1,21 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseBackoff: 2 * time.Second, MaxBackoff: 10 * time.Second}
+
+	assert.Equal(t, 2*time.Second, policy.Backoff(1))
+	assert.Equal(t, 4*time.Second, policy.Backoff(2))
+	assert.Equal(t, 8*time.Second, policy.Backoff(3))
+	assert.Equal(t, 10*time.Second, policy.Backoff(4))
+	assert.Equal(t, 10*time.Second, policy.Backoff(60))
+
+	// Without a cap the backoff keeps doubling
+	assert.Equal(t, 16*time.Second, RetryPolicy{BaseBackoff: time.Second}.Backoff(5))
+}
@@ -0,0 +1,112 @@
+package crawler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minutesPerDay is the length of the day quiet windows are laid out on
+const minutesPerDay = 24 * 60
+
+// Schedule describes how often a crawler runs
+type Schedule struct {
+	// Interval is the time between the end of one run and the start of the
+	// next; the worker's default interval is used when zero
+	Interval time.Duration
+	// Jitter is the most time added at random to each interval so that
+	// crawlers started together drift apart
+	Jitter time.Duration
+	// MinInterval and MaxInterval bound the interval when it adapts to how
+	// often deals are posted; a zero bound defaults to the interval itself
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	// QuietHours are the daily windows, in KST, during which the crawler does not run
+	QuietHours []QuietWindow
+}
+
+// QuietWindow is a daily window in minutes since midnight KST.
+// A window that ends before it starts wraps past midnight, e.g. 23:00-06:00.
+type QuietWindow struct {
+	Start int
+	End   int
+}
+
+// ParseQuietWindow parses a window written as "HH:MM-HH:MM"
+func ParseQuietWindow(value string) (QuietWindow, error) {
+	start, end, found := strings.Cut(value, "-")
+	if !found {
+		return QuietWindow{}, fmt.Errorf("quiet hours %q must look like 01:00-07:00", value)
+	}
+
+	startMinute, err := parseMinuteOfDay(start)
+	if err != nil {
+		return QuietWindow{}, fmt.Errorf("quiet hours %q: %w", value, err)
+	}
+	endMinute, err := parseMinuteOfDay(end)
+	if err != nil {
+		return QuietWindow{}, fmt.Errorf("quiet hours %q: %w", value, err)
+	}
+	if startMinute == endMinute {
+		return QuietWindow{}, fmt.Errorf("quiet hours %q must not be empty", value)
+	}
+
+	return QuietWindow{Start: startMinute, End: endMinute}, nil
+}
+
+// parseMinuteOfDay parses "HH:MM" into minutes since midnight
+func parseMinuteOfDay(value string) (int, error) {
+	hour, minute, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	h, err := strconv.Atoi(hour)
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("invalid hour in %q", value)
+	}
+	m, err := strconv.Atoi(minute)
+	if err != nil || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid minute in %q", value)
+	}
+	return h*60 + m, nil
+}
+
+// contains reports whether the minute of the day falls within the window
+func (q QuietWindow) contains(minute int) bool {
+	if q.Start < q.End {
+		return minute >= q.Start && minute < q.End
+	}
+	return minute >= q.Start || minute < q.End
+}
+
+// Adaptive reports whether the interval adapts to how often deals are posted
+func (s Schedule) Adaptive() bool {
+	return s.MinInterval > 0 || s.MaxInterval > 0
+}
+
+// Resume returns the earliest time from t on that is outside the quiet hours
+func (s Schedule) Resume(t time.Time) time.Time {
+	// Each pass leaves one window, so adjacent windows take several passes
+	for i := 0; i <= len(s.QuietHours); i++ {
+		local := t.In(KST)
+		minute := local.Hour()*60 + local.Minute()
+
+		quiet := false
+		for _, window := range s.QuietHours {
+			if !window.contains(minute) {
+				continue
+			}
+			quiet = true
+
+			wait := (window.End - minute + minutesPerDay) % minutesPerDay
+			midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, KST)
+			t = midnight.Add(time.Duration(minute+wait) * time.Minute)
+			break
+		}
+		if !quiet {
+			return t
+		}
+	}
+	return t
+}
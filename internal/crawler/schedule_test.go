@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuietWindow(t *testing.T) {
+	window, err := ParseQuietWindow("01:30-07:00")
+	assert.NoError(t, err)
+	assert.Equal(t, QuietWindow{Start: 90, End: 420}, window)
+
+	window, err = ParseQuietWindow(" 23:00 - 24:00 ")
+	assert.NoError(t, err)
+	assert.Equal(t, QuietWindow{Start: 23 * 60, End: 24 * 60}, window)
+
+	for _, value := range []string{"", "01:00", "1-7", "25:00-07:00", "01:60-07:00", "24:30-01:00", "07:00-07:00"} {
+		_, err := ParseQuietWindow(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestScheduleResume(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, 0, 0, KST)
+	}
+
+	// Without quiet hours the time is returned as it is
+	assert.Equal(t, at(1, 3, 0), Schedule{}.Resume(at(1, 3, 0)))
+
+	schedule := Schedule{QuietHours: []QuietWindow{{Start: 2 * 60, End: 6 * 60}}}
+	assert.Equal(t, at(1, 1, 59), schedule.Resume(at(1, 1, 59)))
+	assert.Equal(t, at(1, 6, 0), schedule.Resume(at(1, 2, 0)))
+	assert.Equal(t, at(1, 6, 0), schedule.Resume(at(1, 5, 30)))
+	assert.Equal(t, at(1, 6, 0), schedule.Resume(at(1, 6, 0)))
+
+	// A window wrapping past midnight ends on the next day
+	schedule = Schedule{QuietHours: []QuietWindow{{Start: 23 * 60, End: 6 * 60}}}
+	assert.Equal(t, at(2, 6, 0), schedule.Resume(at(1, 23, 30)))
+	assert.Equal(t, at(2, 6, 0), schedule.Resume(at(2, 0, 10)))
+
+	// Adjacent windows are left together
+	schedule = Schedule{QuietHours: []QuietWindow{{Start: 4 * 60, End: 7 * 60}, {Start: 1 * 60, End: 4 * 60}}}
+	assert.Equal(t, at(1, 7, 0), schedule.Resume(at(1, 2, 0)))
+
+	// Times in other zones are judged in KST
+	utc := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC) // 03:00 KST
+	schedule = Schedule{QuietHours: []QuietWindow{{Start: 2 * 60, End: 6 * 60}}}
+	assert.True(t, at(2, 6, 0).Equal(schedule.Resume(utc)))
+}
@@ -0,0 +1,586 @@
+package crawler
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"sjsage522/hotdealworker/helpers"
+
+	"github.com/PuerkitoBio/goquery"
+	"gopkg.in/yaml.v3"
+)
+
+// Fetch strategies a site definition can choose
+const (
+	FetchStandard = "standard"
+	FetchChrome   = "chrome"
+)
+
+// embeddedSites holds the built-in site definitions
+//
+//go:embed sites/*.yaml
+var embeddedSites embed.FS
+
+// SiteDefinition describes how to crawl one board.
+// Definitions are written in YAML (or JSON) so that a selector fix does not need a rebuild.
+type SiteDefinition struct {
+	// Name is the crawler's config key, e.g. "ppom" for CRAWLER_PPOM_ENABLED
+	Name     string `yaml:"name"`
+	Provider string `yaml:"provider"`
+
+	// SiteURL is the site's root; the crawler URL from the config overrides it
+	SiteURL string `yaml:"site_url"`
+	// Path is appended to the site URL to build the board URL
+	Path string `yaml:"path"`
+	// LinkBase is appended to the site URL to resolve relative links (defaults to the site URL)
+	LinkBase string `yaml:"link_base"`
+
+	CacheKey  string `yaml:"cache_key"`
+	BlockTime int    `yaml:"block_time"`
+	// Fetch is either "standard" or "chrome"
+	Fetch string `yaml:"fetch"`
+	// ImageReferer is sent when downloading thumbnails from sites that check it
+	ImageReferer string `yaml:"image_referer"`
+	// Timeout bounds a whole crawl of the site, detail pages included, e.g. "150s"
+	// (defaults to CRAWL_TIMEOUT_SECONDS)
+	Timeout time.Duration `yaml:"timeout"`
+	// Schedule sets how often the site is crawled
+	Schedule ScheduleRule `yaml:"schedule"`
+	// Retry sets how a failed crawl is retried within the same run
+	Retry RetryRule `yaml:"retry"`
+
+	ID        IDRule        `yaml:"id"`
+	Pages     PageRule      `yaml:"pages"`
+	Selectors SiteSelectors `yaml:"selectors"`
+	// Detail reads the detail page of new deals; without it no detail page is fetched
+	Detail DetailDefinition `yaml:"detail"`
+}
+
+// IDRule extracts a deal ID from its link by splitting it
+type IDRule struct {
+	// StripQuery drops everything after "?" before splitting
+	StripQuery bool   `yaml:"strip_query"`
+	Split      string `yaml:"split"`
+	Index      int    `yaml:"index"`
+}
+
+// PageRule builds the URLs of the pages after the first
+type PageRule struct {
+	// Path is appended to the site URL with "{page}" replaced by the page number.
+	// Without it only the first page is crawled.
+	Path string `yaml:"path"`
+	// First is the number the board gives its first page (defaults to 1)
+	First *int `yaml:"first"`
+	// Max caps the pages fetched while catching up (defaults to CATCHUP_MAX_PAGES)
+	Max int `yaml:"max"`
+}
+
+// ScheduleRule sets how often a site is crawled
+type ScheduleRule struct {
+	// Interval between runs, e.g. "30s" (defaults to CRAWL_INTERVAL_SECONDS)
+	Interval time.Duration `yaml:"interval"`
+	// Jitter is the most time added at random to each interval
+	Jitter time.Duration `yaml:"jitter"`
+	// MinInterval and MaxInterval let the interval adapt to how busy the board is;
+	// the interval stays fixed when neither is set
+	MinInterval time.Duration `yaml:"min_interval"`
+	MaxInterval time.Duration `yaml:"max_interval"`
+	// QuietHours are daily windows in KST, e.g. "01:00-07:00", during which the site is not crawled
+	QuietHours []string `yaml:"quiet_hours"`
+}
+
+// RetryRule sets how a failed crawl of a site is retried; unset fields
+// default to the RETRY_* settings
+type RetryRule struct {
+	// MaxAttempts is how many times the crawl is tried, the first included
+	MaxAttempts int `yaml:"max_attempts"`
+	// BaseBackoff is the wait before the first retry, e.g. "2s"; it doubles
+	// with each retry up to MaxBackoff
+	BaseBackoff time.Duration `yaml:"base_backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+	// Jitter is the most time added at random to each wait
+	Jitter time.Duration `yaml:"jitter"`
+}
+
+// SiteSelectors holds the selectors and field extractors of a site definition
+type SiteSelectors struct {
+	DealList    string `yaml:"deal_list"`
+	Link        string `yaml:"link"`
+	Thumbnail   string `yaml:"thumbnail"`
+	PriceRegex  string `yaml:"price_regex"`
+	ThumbRegex  string `yaml:"thumb_regex"`
+	ClassFilter string `yaml:"class_filter"`
+	EndedClass  string `yaml:"ended_class"`
+
+	Title    Field `yaml:"title"`
+	Price    Field `yaml:"price"`
+	Shipping Field `yaml:"shipping"`
+	PostedAt Field `yaml:"posted_at"`
+	Category Field `yaml:"category"`
+	Status   Field `yaml:"status"`
+	Votes    Field `yaml:"votes"`
+	Comments Field `yaml:"comments"`
+	Views    Field `yaml:"views"`
+}
+
+// DetailDefinition holds the field extractors for a deal's detail page.
+// Extractors are applied to the whole document.
+type DetailDefinition struct {
+	ShopURL  Field `yaml:"shop_url"`
+	Shipping Field `yaml:"shipping"`
+	Body     Field `yaml:"body"`
+	// Images selects the images of the post
+	Images string `yaml:"images"`
+}
+
+// Field is a list of extractors tried in order until one yields a value.
+// In YAML it can be written as a selector string, a single extractor or a list of them.
+type Field []Extractor
+
+// Extractor reads a value from a deal row with a small set of transforms,
+// applied in the order the fields are declared
+type Extractor struct {
+	// Selector finds the element within the row; empty means the row itself
+	Selector string `yaml:"selector"`
+	// Nth picks a single element (0-based) when the selector matches several
+	Nth *int `yaml:"nth"`
+	// Value is returned as-is when the selector matches, e.g. a status
+	Value string `yaml:"value"`
+	// Attr reads an attribute instead of the text
+	Attr string `yaml:"attr"`
+	// Remove drops child elements before reading the text, e.g. ["span"]
+	Remove []string `yaml:"remove"`
+	// StyleURL extracts the url(...) from the style attribute
+	StyleURL bool `yaml:"style_url"`
+	// After keeps the text after the marker; the extractor yields nothing without it
+	After string `yaml:"after"`
+	// Before keeps the text before the marker when present
+	Before string `yaml:"before"`
+	// StripPrefix removes a leading label
+	StripPrefix string `yaml:"strip_prefix"`
+	// Split keeps one part of the text
+	Split *SplitRule `yaml:"split"`
+	// Regex keeps the first match, or its first group if it has one
+	Regex string `yaml:"regex"`
+	// Suffix is appended to a non-empty result
+	Suffix string `yaml:"suffix"`
+
+	regex *regexp.Regexp
+}
+
+// SplitRule keeps the part at Index after splitting on Sep
+type SplitRule struct {
+	Sep   string `yaml:"sep"`
+	Index int    `yaml:"index"`
+}
+
+// styleURLRegex matches a url(...) in a style attribute
+var styleURLRegex = regexp.MustCompile(`url\((?:['"]?)(.*?)(?:['"]?)\)`)
+
+// UnmarshalYAML accepts a plain selector string as shorthand for an extractor
+func (e *Extractor) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		e.Selector = value.Value
+		return nil
+	}
+
+	type plain Extractor
+	return value.Decode((*plain)(e))
+}
+
+// UnmarshalYAML accepts a single extractor as well as a list of them
+func (f *Field) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		var extractors []Extractor
+		if err := value.Decode(&extractors); err != nil {
+			return err
+		}
+		*f = extractors
+		return nil
+	}
+
+	var extractor Extractor
+	if err := value.Decode(&extractor); err != nil {
+		return err
+	}
+	*f = Field{extractor}
+	return nil
+}
+
+// isPlainSelector reports whether the field is just a selector, so the
+// crawler's default handler can read it
+func (f Field) isPlainSelector() bool {
+	if len(f) != 1 {
+		return false
+	}
+	e := f[0]
+	return e.Selector != "" && e.Nth == nil && e.Value == "" && e.Attr == "" &&
+		len(e.Remove) == 0 && !e.StyleURL && e.After == "" && e.Before == "" &&
+		e.StripPrefix == "" && e.Split == nil && e.Regex == "" && e.Suffix == ""
+}
+
+// selectorOrHandlers returns the field as a selector for the default handler
+// when it is plain, and as element handlers otherwise
+func (f Field) selectorOrHandlers() (string, []ElementHandler) {
+	if f.isPlainSelector() {
+		return f[0].Selector, nil
+	}
+	return "", f.handlers()
+}
+
+// handlers returns an element handler for every extractor of the field
+func (f Field) handlers() []ElementHandler {
+	if len(f) == 0 {
+		return nil
+	}
+
+	handlers := make([]ElementHandler, len(f))
+	for i := range f {
+		handlers[i] = f[i].extract
+	}
+	return handlers
+}
+
+// compile prepares the extractors and reports the first invalid one
+func (f Field) compile() error {
+	for i := range f {
+		if f[i].Regex == "" {
+			continue
+		}
+		re, err := regexp.Compile(f[i].Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %w", f[i].Regex, err)
+		}
+		f[i].regex = re
+	}
+	return nil
+}
+
+// extract applies the extractor to a deal row
+func (e *Extractor) extract(s *goquery.Selection) string {
+	sel := s
+	if e.Selector != "" {
+		sel = s.Find(e.Selector)
+	}
+	if e.Nth != nil {
+		sel = sel.Eq(*e.Nth)
+	}
+	if sel.Length() == 0 {
+		return ""
+	}
+
+	if e.Value != "" {
+		return e.Value
+	}
+
+	var text string
+	switch {
+	case e.StyleURL:
+		if matches := styleURLRegex.FindStringSubmatch(sel.AttrOr("style", "")); len(matches) > 1 {
+			text = matches[1]
+		}
+	case e.Attr != "":
+		text = sel.AttrOr(e.Attr, "")
+	case len(e.Remove) > 0:
+		// Clone to avoid modifying the original selection
+		clone := sel.Clone()
+		for _, child := range e.Remove {
+			clone.Find(child).Remove()
+		}
+		text = clone.Text()
+	default:
+		text = sel.Text()
+	}
+	text = strings.TrimSpace(text)
+
+	if e.After != "" {
+		index := strings.Index(text, e.After)
+		if index == -1 {
+			return ""
+		}
+		text = text[index+len(e.After):]
+	}
+	if e.Before != "" {
+		if index := strings.Index(text, e.Before); index != -1 {
+			text = text[:index]
+		}
+	}
+	text = strings.TrimSpace(text)
+
+	if e.StripPrefix != "" {
+		text = strings.TrimSpace(strings.TrimPrefix(text, e.StripPrefix))
+	}
+
+	if e.Split != nil {
+		part, err := helpers.GetSplitPart(text, e.Split.Sep, e.Split.Index)
+		if err != nil {
+			return ""
+		}
+		text = strings.TrimSpace(part)
+	}
+
+	if e.regex != nil {
+		matches := e.regex.FindStringSubmatch(text)
+		switch {
+		case matches == nil:
+			return ""
+		case len(matches) > 1:
+			text = matches[1]
+		default:
+			text = matches[0]
+		}
+		text = strings.TrimSpace(text)
+	}
+
+	if text != "" {
+		text += e.Suffix
+	}
+	return text
+}
+
+// Validate checks that the definition is complete and its regexes compile
+func (d *SiteDefinition) Validate() error {
+	switch {
+	case d.Name == "":
+		return fmt.Errorf("name is required")
+	case d.Provider == "":
+		return fmt.Errorf("%s: provider is required", d.Name)
+	case d.SiteURL == "":
+		return fmt.Errorf("%s: site_url is required", d.Name)
+	case d.Selectors.DealList == "":
+		return fmt.Errorf("%s: selectors.deal_list is required", d.Name)
+	case d.Selectors.Link == "":
+		return fmt.Errorf("%s: selectors.link is required", d.Name)
+	case len(d.Selectors.Title) == 0:
+		return fmt.Errorf("%s: selectors.title is required", d.Name)
+	}
+
+	if d.Fetch != "" && d.Fetch != FetchStandard && d.Fetch != FetchChrome {
+		return fmt.Errorf("%s: unknown fetch strategy %q", d.Name, d.Fetch)
+	}
+	if d.ID.Split == "" && d.ID.Index != 0 {
+		return fmt.Errorf("%s: id.split is required when id.index is set", d.Name)
+	}
+	if d.Pages.Path != "" && !strings.Contains(d.Pages.Path, "{page}") {
+		return fmt.Errorf("%s: pages.path must contain {page}", d.Name)
+	}
+	if d.Pages.First != nil && *d.Pages.First < 0 {
+		return fmt.Errorf("%s: pages.first must not be negative", d.Name)
+	}
+	if d.Pages.Max < 0 {
+		return fmt.Errorf("%s: pages.max must not be negative", d.Name)
+	}
+	if d.Timeout < 0 {
+		return fmt.Errorf("%s: timeout must not be negative", d.Name)
+	}
+	if d.Schedule.Interval != 0 && d.Schedule.Interval < 10*time.Second {
+		return fmt.Errorf("%s: schedule.interval must be at least 10 seconds", d.Name)
+	}
+	if d.Schedule.Jitter < 0 {
+		return fmt.Errorf("%s: schedule.jitter must not be negative", d.Name)
+	}
+	if d.Schedule.MinInterval != 0 && d.Schedule.MinInterval < 10*time.Second {
+		return fmt.Errorf("%s: schedule.min_interval must be at least 10 seconds", d.Name)
+	}
+	if d.Schedule.MaxInterval < 0 {
+		return fmt.Errorf("%s: schedule.max_interval must not be negative", d.Name)
+	}
+	if d.Schedule.MaxInterval != 0 && d.Schedule.MaxInterval < d.Schedule.MinInterval {
+		return fmt.Errorf("%s: schedule.max_interval must not be less than schedule.min_interval", d.Name)
+	}
+	if d.Retry.MaxAttempts < 0 {
+		return fmt.Errorf("%s: retry.max_attempts must not be negative", d.Name)
+	}
+	if d.Retry.BaseBackoff < 0 || d.Retry.MaxBackoff < 0 || d.Retry.Jitter < 0 {
+		return fmt.Errorf("%s: retry backoff and jitter must not be negative", d.Name)
+	}
+	if d.Retry.MaxBackoff != 0 && d.Retry.MaxBackoff < d.Retry.BaseBackoff {
+		return fmt.Errorf("%s: retry.max_backoff must not be less than retry.base_backoff", d.Name)
+	}
+	for _, window := range d.Schedule.QuietHours {
+		if _, err := ParseQuietWindow(window); err != nil {
+			return fmt.Errorf("%s: schedule: %w", d.Name, err)
+		}
+	}
+
+	for _, pattern := range []string{d.Selectors.PriceRegex, d.Selectors.ThumbRegex} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%s: invalid regex %q: %w", d.Name, pattern, err)
+		}
+	}
+
+	fields := map[string]Field{
+		"selectors.title":     d.Selectors.Title,
+		"selectors.price":     d.Selectors.Price,
+		"selectors.shipping":  d.Selectors.Shipping,
+		"selectors.posted_at": d.Selectors.PostedAt,
+		"selectors.category":  d.Selectors.Category,
+		"selectors.status":    d.Selectors.Status,
+		"selectors.votes":     d.Selectors.Votes,
+		"selectors.comments":  d.Selectors.Comments,
+		"selectors.views":     d.Selectors.Views,
+		"detail.shop_url":     d.Detail.ShopURL,
+		"detail.shipping":     d.Detail.Shipping,
+		"detail.body":         d.Detail.Body,
+	}
+	for name, field := range fields {
+		if err := field.compile(); err != nil {
+			return fmt.Errorf("%s: %s: %w", d.Name, name, err)
+		}
+	}
+
+	return nil
+}
+
+// CrawlerConfig turns the definition into the configuration of a unified crawler.
+// siteURL overrides the definition's site URL when set.
+func (d *SiteDefinition) CrawlerConfig(siteURL, chromeDBAddr string) CrawlerConfig {
+	if siteURL == "" {
+		siteURL = d.SiteURL
+	}
+	siteURL = strings.TrimRight(siteURL, "/")
+
+	sel := d.Selectors
+	selectors := Selectors{
+		DealList:         sel.DealList,
+		Link:             sel.Link,
+		Thumbnail:        sel.Thumbnail,
+		PriceRegex:       sel.PriceRegex,
+		ThumbRegex:       sel.ThumbRegex,
+		ClassFilter:      sel.ClassFilter,
+		EndedClass:       sel.EndedClass,
+		PriceHandlers:    sel.Price.handlers(),
+		ShippingHandlers: sel.Shipping.handlers(),
+		StatusHandlers:   sel.Status.handlers(),
+	}
+	selectors.Title, selectors.TitleHandlers = sel.Title.selectorOrHandlers()
+	selectors.PostedAt, selectors.PostedAtHandlers = sel.PostedAt.selectorOrHandlers()
+	selectors.Category, selectors.CategoryHandlers = sel.Category.selectorOrHandlers()
+	selectors.Votes, selectors.VoteHandlers = sel.Votes.selectorOrHandlers()
+	selectors.Comments, selectors.CommentHandlers = sel.Comments.selectorOrHandlers()
+	selectors.Views, selectors.ViewHandlers = sel.Views.selectorOrHandlers()
+
+	var idExtractor IDExtractorFunc
+	if d.ID.Split != "" {
+		rule := d.ID
+		idExtractor = func(link string) (string, error) {
+			if rule.StripQuery {
+				link = strings.Split(link, "?")[0]
+			}
+			return helpers.GetSplitPart(link, rule.Split, rule.Index)
+		}
+	}
+
+	var pageURL string
+	if d.Pages.Path != "" {
+		pageURL = siteURL + d.Pages.Path
+	}
+	pageOffset := 0
+	if d.Pages.First != nil {
+		pageOffset = *d.Pages.First - 1
+	}
+
+	schedule := Schedule{
+		Interval:    d.Schedule.Interval,
+		Jitter:      d.Schedule.Jitter,
+		MinInterval: d.Schedule.MinInterval,
+		MaxInterval: d.Schedule.MaxInterval,
+	}
+	for _, value := range d.Schedule.QuietHours {
+		// Validate has already rejected malformed windows
+		if window, err := ParseQuietWindow(value); err == nil {
+			schedule.QuietHours = append(schedule.QuietHours, window)
+		}
+	}
+
+	return CrawlerConfig{
+		URL:          siteURL + d.Path,
+		CacheKey:     d.CacheKey,
+		BlockTime:    d.BlockTime,
+		BaseURL:      siteURL + d.LinkBase,
+		Provider:     d.Provider,
+		Selectors:    selectors,
+		IDExtractor:  idExtractor,
+		UseChrome:    d.Fetch == FetchChrome,
+		ChromeDBAddr: chromeDBAddr,
+		ImageReferer: d.ImageReferer,
+		Timeout:      d.Timeout,
+		Schedule:     schedule,
+		Retry: RetryPolicy{
+			MaxAttempts: d.Retry.MaxAttempts,
+			BaseBackoff: d.Retry.BaseBackoff,
+			MaxBackoff:  d.Retry.MaxBackoff,
+			Jitter:      d.Retry.Jitter,
+		},
+		PageURL:    pageURL,
+		PageOffset: pageOffset,
+		MaxPages:   d.Pages.Max,
+		Detail: DetailSelectors{
+			ShopURLHandlers:  d.Detail.ShopURL.handlers(),
+			ShippingHandlers: d.Detail.Shipping.handlers(),
+			BodyHandlers:     d.Detail.Body.handlers(),
+			Images:           d.Detail.Images,
+		},
+	}
+}
+
+// siteFile is a site definition file and the result of parsing it
+type siteFile struct {
+	path string
+	data []byte
+	def  SiteDefinition
+	err  error
+}
+
+// readSiteFiles reads and parses every YAML or JSON file in the directory, in name order.
+// Files that fail to parse are returned with their error.
+func readSiteFiles(fsys fs.FS, dir string) ([]siteFile, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []siteFile
+	for _, entry := range entries {
+		if entry.IsDir() || !isSiteFile(entry.Name()) {
+			continue
+		}
+
+		file := siteFile{path: entry.Name()}
+		file.data, file.err = fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if file.err == nil {
+			file.def, file.err = ParseSiteDefinition(file.data)
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// isSiteFile reports whether the file name has a site definition extension
+func isSiteFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseSiteDefinition parses and validates a single YAML or JSON site definition
+func ParseSiteDefinition(data []byte) (SiteDefinition, error) {
+	var def SiteDefinition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return SiteDefinition{}, err
+	}
+	if err := def.Validate(); err != nil {
+		return SiteDefinition{}, err
+	}
+	return def, nil
+}
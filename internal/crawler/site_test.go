@@ -0,0 +1,252 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+// builtInSites returns the built-in site definitions by name
+func builtInSites(t *testing.T) map[string]*SiteDefinition {
+	files, err := readSiteFiles(embeddedSites, "sites")
+	assert.NoError(t, err)
+
+	defs := make(map[string]*SiteDefinition, len(files))
+	for i := range files {
+		assert.NoError(t, files[i].err, files[i].path)
+		defs[files[i].def.Name] = &files[i].def
+	}
+	return defs
+}
+
+func TestSiteDefinitionCrawlerConfig(t *testing.T) {
+	ppom := builtInSites(t)["ppom"]
+
+	crawlerCfg := ppom.CrawlerConfig("", "http://chromedb:3000")
+	assert.Equal(t, "https://www.ppomppu.co.kr/zboard/zboard.php?id=ppomppu", crawlerCfg.URL)
+	assert.Equal(t, "https://www.ppomppu.co.kr/zboard/", crawlerCfg.BaseURL)
+	assert.Equal(t, ProviderPpom, crawlerCfg.Provider)
+	assert.False(t, crawlerCfg.UseChrome)
+
+	id, err := crawlerCfg.IDExtractor("https://www.ppomppu.co.kr/zboard/view.php?id=ppomppu&no=123")
+	assert.NoError(t, err)
+	assert.Equal(t, "123", id)
+
+	// The crawler URL from the config overrides the site URL
+	crawlerCfg = ppom.CrawlerConfig("http://localhost:8080/", "")
+	assert.Equal(t, "http://localhost:8080/zboard/zboard.php?id=ppomppu", crawlerCfg.URL)
+}
+
+func TestSiteDefinitionPages(t *testing.T) {
+	byName := builtInSites(t)
+
+	ruliweb := NewUnifiedCrawler(byName["ruliweb"].CrawlerConfig("", ""), nil)
+	assert.Equal(t, "https://bbs.ruliweb.com/market/board/1020?view=thumbnail", ruliweb.PageURLFor(1))
+	assert.Equal(t, "https://bbs.ruliweb.com/market/board/1020?view=thumbnail&page=3", ruliweb.PageURLFor(3))
+
+	// Clien numbers its first page 0
+	clien := NewUnifiedCrawler(byName["clien"].CrawlerConfig("", ""), nil)
+	assert.Equal(t, "https://www.clien.net/service/board/jirum?po=1", clien.PageURLFor(2))
+
+	// Boards without a page path only have the first page
+	missy := NewUnifiedCrawler(byName["missycoupons"].CrawlerConfig("", ""), nil)
+	assert.Equal(t, "", missy.PageURLFor(2))
+}
+
+func TestSiteDefinitionTimeout(t *testing.T) {
+	timeouts := make(map[string]time.Duration)
+	for name, def := range builtInSites(t) {
+		timeouts[name] = NewUnifiedCrawler(def.CrawlerConfig("", ""), nil).CrawlTimeout()
+	}
+
+	// Sites fetched through ChromeDB wait for the FlareSolverr fallback
+	assert.Equal(t, 150*time.Second, timeouts["fmkorea"])
+	// Other sites take the configured default from the reloader
+	assert.Equal(t, time.Duration(0), timeouts["ppom"])
+}
+
+func TestSiteDefinitionSchedule(t *testing.T) {
+	schedules := make(map[string]Schedule)
+	for name, def := range builtInSites(t) {
+		schedules[name] = NewUnifiedCrawler(def.CrawlerConfig("", ""), nil).CrawlSchedule()
+	}
+
+	assert.Equal(t, 30*time.Second, schedules["ppom"].Interval)
+	assert.True(t, schedules["ppom"].Adaptive())
+	assert.Equal(t, 20*time.Second, schedules["ppom"].MinInterval)
+	assert.False(t, schedules["fmkorea"].Adaptive())
+	assert.Equal(t, 3*time.Minute, schedules["fmkorea"].Interval)
+	assert.Equal(t, []QuietWindow{{Start: 3 * 60, End: 7 * 60}}, schedules["fmkorea"].QuietHours)
+	// Sites without a schedule run at the worker's interval
+	assert.Equal(t, Schedule{}, schedules["clien"])
+}
+
+func TestExtractorTransforms(t *testing.T) {
+	html := `<table><tr class="row" data-category="가전">
+		<td class="title"><a href="/1">신라면 <span class="cmt">[3]</span></a></td>
+		<td class="meta">가격: 12,000원 배송비: 무료</td>
+		<td class="date">날짜 25.10.16</td>
+		<td class="code">A/B/C</td>
+		<td class="thumb" style="background-image: url('/img/1.jpg')"></td>
+	</tr></table>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	assert.NoError(t, err)
+	row := doc.Find("tr.row")
+
+	nth := 1
+	testCases := []struct {
+		name      string
+		extractor Extractor
+		expect    string
+	}{
+		{"text", Extractor{Selector: "td.date"}, "날짜 25.10.16"},
+		{"remove", Extractor{Selector: "td.title a", Remove: []string{"span"}}, "신라면"},
+		{"nth", Extractor{Selector: "td", Nth: &nth}, "가격: 12,000원 배송비: 무료"},
+		{"attr of row", Extractor{Attr: "data-category"}, "가전"},
+		{"strip prefix", Extractor{Selector: "td.date", StripPrefix: "날짜"}, "25.10.16"},
+		{"split", Extractor{Selector: "td.code", Split: &SplitRule{Sep: "/", Index: 2}}, "C"},
+		{"split out of range", Extractor{Selector: "td.code", Split: &SplitRule{Sep: "/", Index: 5}}, ""},
+		{"after and before", Extractor{Selector: "td.meta", After: "가격:", Before: "배송비:"}, "12,000원"},
+		{"after missing", Extractor{Selector: "td.meta", After: "할인:"}, ""},
+		{"regex and suffix", Extractor{Selector: "td.meta", Regex: `[0-9,]+`, Suffix: "원"}, "12,000원"},
+		{"style url", Extractor{Selector: "td.thumb", StyleURL: true}, "/img/1.jpg"},
+		{"value", Extractor{Selector: "td.title a", Value: StatusEnded}, StatusEnded},
+		{"missing element", Extractor{Selector: "td.none", Value: StatusEnded}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			field := Field{tc.extractor}
+			assert.NoError(t, field.compile())
+			assert.Equal(t, tc.expect, field[0].extract(row))
+		})
+	}
+}
+
+func TestZodPriceFallbacks(t *testing.T) {
+	zod := builtInSites(t)["zod"]
+
+	row := func(id, meta string) string {
+		return `<li>
+			<a class="tw-flex-1" href="/deal/` + id + `"><div class="tw-flex-1"><div class="app-list-title tw-flex-wrap">
+				<span class="tw-mr-1 app-list-title-item">Deal ` + id + `</span>
+			</div></div></a>
+			<div class="app-list-meta zod-board--deal-meta tw-mt-1"><span>` + meta + `</span></div>
+		</li>`
+	}
+
+	crawler := NewUnifiedCrawler(zod.CrawlerConfig("", ""), nil)
+	crawler.fetchFunc = func(context.Context, string) (io.Reader, error) {
+		html := `<html><body><ul class="app-board-template-list zod-board-list--deal">` +
+			row("1", "가격: 12,000원 배송비: 무료") +
+			row("2", "가격: 무료 배송비: 3,000원") +
+			row("3", "가격: 가격 다양 배송비: 무료") +
+			row("4", "15,000원 무료배송") +
+			`</ul></body></html>`
+		return strings.NewReader(html), nil
+	}
+
+	deals, err := crawler.FetchDeals(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, deals, 4) {
+		assert.Equal(t, "12,000원", deals[0].Price)
+
+		// Prices without digits are kept as written, not taken from the shipping fee
+		assert.Equal(t, "무료", deals[1].Price)
+		assert.Equal(t, PriceFlagFree, deals[1].PriceFlag)
+		assert.Equal(t, "가격 다양", deals[2].Price)
+
+		// Without the label the price is searched across the whole meta text
+		assert.Equal(t, "15,000원", deals[3].Price)
+	}
+}
+
+func TestParseSiteDefinition(t *testing.T) {
+	def, err := ParseSiteDefinition([]byte(`
+name: test
+provider: TestProvider
+site_url: https://example.com
+fetch: chrome
+id:
+  split: /
+  index: 3
+selectors:
+  deal_list: div.deal
+  link: a.link
+  title:
+    selector: a.link
+    remove: [span]
+  price:
+    - selector: div.meta
+      after: "가격:"
+    - div.price
+  posted_at: span.date
+  status:
+    selector: a.link.ended
+    value: ended
+`))
+	assert.NoError(t, err)
+	assert.Len(t, def.Selectors.Price, 2)
+	assert.Equal(t, "div.price", def.Selectors.Price[1].Selector)
+
+	crawlerCfg := def.CrawlerConfig("", "")
+	assert.True(t, crawlerCfg.UseChrome)
+	assert.Len(t, crawlerCfg.Selectors.TitleHandlers, 1)
+	assert.Equal(t, "span.date", crawlerCfg.Selectors.PostedAt)
+	assert.Empty(t, crawlerCfg.Selectors.PostedAtHandlers)
+
+	crawler := NewUnifiedCrawler(crawlerCfg, nil)
+	crawler.fetchFunc = func(context.Context, string) (io.Reader, error) {
+		html := `<html><body>
+			<div class="deal">
+				<a class="link" href="/1">Deal One <span>[5]</span></a>
+				<div class="price">10,000원</div>
+			</div>
+			<div class="deal">
+				<a class="link ended" href="/2">Deal Two</a>
+				<div class="meta">가격: 20,000원</div>
+			</div>
+		</body></html>`
+		return strings.NewReader(html), nil
+	}
+
+	deals, err := crawler.FetchDeals(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, deals, 2) {
+		assert.Equal(t, "1", deals[0].Id)
+		assert.Equal(t, "Deal One", deals[0].Title)
+		assert.Equal(t, "10,000원", deals[0].Price)
+		assert.Equal(t, StatusActive, deals[0].Status)
+
+		assert.Equal(t, "20,000원", deals[1].Price)
+		assert.Equal(t, StatusEnded, deals[1].Status)
+	}
+}
+
+func TestParseSiteDefinitionInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"missing name":   "provider: P\nsite_url: https://example.com\nselectors: {deal_list: div, link: a, title: a}",
+		"missing title":  "name: x\nprovider: P\nsite_url: https://example.com\nselectors: {deal_list: div, link: a}",
+		"bad fetch":      "name: x\nprovider: P\nsite_url: https://example.com\nfetch: curl\nselectors: {deal_list: div, link: a, title: a}",
+		"bad page path":  "name: x\nprovider: P\nsite_url: https://example.com\npages: {path: /list}\nselectors: {deal_list: div, link: a, title: a}",
+		"bad timeout":    "name: x\nprovider: P\nsite_url: https://example.com\ntimeout: -1s\nselectors: {deal_list: div, link: a, title: a}",
+		"bad interval":   "name: x\nprovider: P\nsite_url: https://example.com\nschedule: {interval: 1s}\nselectors: {deal_list: div, link: a, title: a}",
+		"bad bounds":     "name: x\nprovider: P\nsite_url: https://example.com\nschedule: {min_interval: 5m, max_interval: 1m}\nselectors: {deal_list: div, link: a, title: a}",
+		"bad retry":      "name: x\nprovider: P\nsite_url: https://example.com\nretry: {base_backoff: 1m, max_backoff: 10s}\nselectors: {deal_list: div, link: a, title: a}",
+		"bad quiet hour": "name: x\nprovider: P\nsite_url: https://example.com\nschedule: {quiet_hours: ['25:00-07:00']}\nselectors: {deal_list: div, link: a, title: a}",
+		"bad regex":      "name: x\nprovider: P\nsite_url: https://example.com\nselectors: {deal_list: div, link: a, title: {selector: a, regex: '('}}",
+		"malformed yaml": "name: [x",
+	}
+
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseSiteDefinition([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
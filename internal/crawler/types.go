@@ -1,6 +1,19 @@
 package crawler
 
-import "github.com/PuerkitoBio/goquery"
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"sjsage522/hotdealworker/internal/merchant"
+	"sjsage522/hotdealworker/services/imagestore"
+	"sjsage522/hotdealworker/services/redirect"
+	"sjsage522/hotdealworker/services/thumbnail"
+
+	"github.com/PuerkitoBio/goquery"
+)
 
 const (
 	ProviderArca         = "Arca"
@@ -20,23 +33,67 @@ const (
 	ProviderRuliweb      = "Ruliweb"
 )
 
+// Deal lifecycle statuses
+const (
+	StatusActive  = "active"
+	StatusEnded   = "ended"
+	StatusSoldOut = "sold_out"
+	StatusDeleted = "deleted"
+)
+
 // HotDeal represents a scraped hot deal
 type HotDeal struct {
-	Id            string `json:"id"`
-	Title         string `json:"title"`
-	Link          string `json:"link"`
-	Price         string `json:"price,omitempty"`
-	Thumbnail     string `json:"thumbnail,omitempty"`
-	ThumbnailLink string `json:"thumbnail_link,omitempty"`
-	PostedAt      string `json:"posted_at,omitempty"`
-	Category      string `json:"category"`
-	Provider      string `json:"provider"`
+	Id            string   `json:"id"`
+	Title         string   `json:"title"`
+	Link          string   `json:"link"`
+	Price         string   `json:"price,omitempty"`
+	PriceAmount   *float64 `json:"price_amount,omitempty"`
+	PriceMax      *float64 `json:"price_max,omitempty"`
+	Currency      string   `json:"currency,omitempty"`
+	ShippingFee   *float64 `json:"shipping_fee,omitempty"`
+	PriceFlag     string   `json:"price_flag,omitempty"`
+	Thumbnail     string   `json:"thumbnail,omitempty"`
+	ThumbnailLink string   `json:"thumbnail_link,omitempty"`
+	ThumbnailKey  string   `json:"thumbnail_key,omitempty"`
+	ThumbnailURL  string   `json:"thumbnail_url,omitempty"`
+	PostedAt      string   `json:"posted_at,omitempty"`
+	PostedAtRaw   string   `json:"posted_at_raw,omitempty"`
+	Category      string   `json:"category"`
+	Votes         *int     `json:"votes,omitempty"`
+	Comments      *int     `json:"comments,omitempty"`
+	Views         *int     `json:"views,omitempty"`
+	Merchant      string   `json:"merchant,omitempty"`
+	ShopURL       string   `json:"shop_url,omitempty"`
+	ShopFinalURL  string   `json:"shop_final_url,omitempty"`
+	ShippingInfo  string   `json:"shipping_info,omitempty"`
+	Body          string   `json:"body,omitempty"`
+	Images        []string `json:"images,omitempty"`
+	Provider      string   `json:"provider"`
+	Status        string   `json:"status"`
+}
+
+// Key returns the identifier of the deal within its provider.
+// Deals without an ID fall back to their link.
+func (d HotDeal) Key() string {
+	if d.Id != "" {
+		return d.Id
+	}
+	return d.Link
+}
+
+// Fingerprint returns a hash of the fields a poster can edit after publishing.
+// Two snapshots of the same deal with different fingerprints mean the deal was updated.
+func (d HotDeal) Fingerprint() string {
+	fields := []string{d.Title, d.Price, d.ThumbnailLink, d.Category}
+	sum := sha1.Sum([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:])
 }
 
 // Crawler interface defines the contract for all crawler implementations
 type Crawler interface {
-	// FetchDeals retrieves hot deals from a source
-	FetchDeals() ([]HotDeal, error)
+	// FetchDeals retrieves hot deals from a source, giving up when the context is done.
+	// It may return ErrUnchanged when the source has not changed since the previous call.
+	FetchDeals(ctx context.Context) ([]HotDeal, error)
 
 	// GetName returns the crawler's name for logging and identification
 	GetName() string
@@ -45,6 +102,73 @@ type Crawler interface {
 	GetProvider() string
 }
 
+// CatchUpCrawler is implemented by crawlers that can page back through a board
+type CatchUpCrawler interface {
+	Crawler
+
+	// FetchDealsUntil fetches pages until one lists a deal for which seen
+	// returns true or the page cap is reached, and returns the deals of
+	// every page fetched in page order
+	FetchDealsUntil(ctx context.Context, seen func(key string) bool) ([]HotDeal, error)
+}
+
+// TimeoutCrawler is implemented by crawlers with a deadline for each crawl
+type TimeoutCrawler interface {
+	Crawler
+
+	// CrawlTimeout returns how long fetching the deals and their detail pages
+	// may take; zero means no deadline
+	CrawlTimeout() time.Duration
+}
+
+// ScheduledCrawler is implemented by crawlers with their own schedule
+type ScheduledCrawler interface {
+	Crawler
+
+	// CrawlSchedule returns how often the crawler runs
+	CrawlSchedule() Schedule
+}
+
+// RetryingCrawler is implemented by crawlers with their own retry policy
+type RetryingCrawler interface {
+	Crawler
+
+	// CrawlRetry returns how a failed crawl is retried
+	CrawlRetry() RetryPolicy
+}
+
+// CommittingCrawler is implemented by crawlers that skip a source unchanged
+// since the last committed fetch
+type CommittingCrawler interface {
+	Crawler
+
+	// CommitFetch makes the latest fetch the one the next fetch is compared
+	// with. It is called once the fetched deals are published, so that deals
+	// of a failed run are fetched and published again.
+	CommitFetch()
+}
+
+// DetailCrawler is implemented by crawlers that can read a deal's detail page
+type DetailCrawler interface {
+	Crawler
+
+	// HasDetail reports whether the crawler knows how to read detail pages
+	HasDetail() bool
+
+	// EnrichDeal fetches the deal's detail page and fills in the fields found there
+	EnrichDeal(ctx context.Context, deal *HotDeal) error
+}
+
+// ThumbnailCrawler is implemented by crawlers that list deals with the URL
+// of their thumbnail and download it only for deals about to be published
+type ThumbnailCrawler interface {
+	Crawler
+
+	// LoadThumbnail downloads the deal's thumbnail into the image store, or into
+	// its Thumbnail field as base64 without one
+	LoadThumbnail(ctx context.Context, deal *HotDeal) error
+}
+
 // ElementHandler defines a function to process a DOM element and return a string value
 type ElementHandler func(*goquery.Selection) string
 
@@ -70,14 +194,33 @@ type Selectors struct {
 	PriceRegex  string
 	ThumbRegex  string
 	ClassFilter string
+	EndedClass  string
+	Votes       string
+	Comments    string
+	Views       string
 
 	// Element handlers for each field
 	TitleHandlers     []ElementHandler
 	LinkHandlers      []ElementHandler
 	PriceHandlers     []ElementHandler
+	ShippingHandlers  []ElementHandler
 	ThumbnailHandlers []ElementHandler
 	PostedAtHandlers  []ElementHandler
 	CategoryHandlers  []ElementHandler
+	StatusHandlers    []ElementHandler
+	VoteHandlers      []ElementHandler
+	CommentHandlers   []ElementHandler
+	ViewHandlers      []ElementHandler
+}
+
+// DetailSelectors contains the handlers that read a deal's detail page.
+// The handlers are applied to the whole document.
+type DetailSelectors struct {
+	ShopURLHandlers  []ElementHandler
+	ShippingHandlers []ElementHandler
+	BodyHandlers     []ElementHandler
+	// Images selects the images of the post
+	Images string
 }
 
 // CrawlerConfig contains configuration for a crawler
@@ -91,4 +234,29 @@ type CrawlerConfig struct {
 	IDExtractor  IDExtractorFunc
 	UseChrome    bool
 	ChromeDBAddr string
+	ImageReferer string
+	Timeout      time.Duration
+	Schedule     Schedule
+	Retry        RetryPolicy
+	PageURL      string
+	PageOffset   int
+	MaxPages     int
+
+	Detail DetailSelectors
+	// DetailLimiter caps concurrent detail page fetches; it is shared between crawlers
+	DetailLimiter Limiter
+	// ImageLimiter caps concurrent thumbnail downloads; it is shared between crawlers
+	ImageLimiter Limiter
+	// ImageCacheTTL is how long an encoded thumbnail is cached by its URL
+	ImageCacheTTL time.Duration
+	// ImageMaxBytes is the largest image downloaded; larger images are rejected
+	ImageMaxBytes int64
+	// Images stores thumbnails; they are inlined in the deal as base64 without it
+	Images imagestore.Store
+	// Thumbnails normalizes downloaded thumbnails; they are kept as downloaded without it
+	Thumbnails *thumbnail.Normalizer
+	// Merchants identifies the shop of a deal; deals have no merchant without it
+	Merchants *merchant.Resolver
+	// Redirects resolves shop links to the page they land on; shop links are not resolved without it
+	Redirects *redirect.Resolver
 }
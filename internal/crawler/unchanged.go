@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"sync"
+
+	"sjsage522/hotdealworker/helpers"
+)
+
+// ErrUnchanged is returned when the first page of a board has not changed
+// since the previous fetch, so there is nothing new to parse or publish
+var ErrUnchanged = errors.New("page unchanged")
+
+var (
+	// volatileMarkup matches markup that differs between requests while the
+	// listing stays the same: scripts, styles, ad slots, comments, meta and
+	// link tags and hidden form tokens
+	volatileMarkup = regexp.MustCompile(`(?is)<script\b.*?</script>|<style\b.*?</style>|<noscript\b.*?</noscript>|<iframe\b.*?</iframe>|<ins\b.*?</ins>|<!--.*?-->|<meta\b[^>]*>|<link\b[^>]*>|<input\b[^>]*type=["']?hidden[^>]*>`)
+
+	// volatileText matches clocks and relative ages that tick on their own
+	volatileText = regexp.MustCompile(`\d{1,2}:\d{2}:\d{2}|\d+\s*(?:초|분|시간)\s*전|방금\s*전?`)
+)
+
+// pageState remembers the first page of a board between fetches. The
+// validators and hash of the latest fetch stay pending until commit, so a
+// run that fails before its deals are published fetches the page again.
+type pageState struct {
+	mu         sync.Mutex
+	validators helpers.Validators
+	hash       string
+
+	pending           bool
+	pendingValidators helpers.Validators
+	pendingHash       string
+}
+
+// conditional returns the validators to send with the next fetch
+func (p *pageState) conditional() helpers.Validators {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.validators
+}
+
+// setValidators records the validators issued with the latest fetch
+func (p *pageState) setValidators(validators helpers.Validators) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = true
+	p.pendingValidators = validators
+	p.pendingHash = p.hash
+}
+
+// changed reports whether the page differs from the last committed fetch once
+// its volatile markup is ignored, and keeps it pending for commit
+func (p *pageState) changed(body []byte) bool {
+	hash := pageHash(body)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.pending {
+		p.pending = true
+		p.pendingValidators = p.validators
+	}
+	p.pendingHash = hash
+	return hash != p.hash
+}
+
+// commit makes the latest fetch the one later fetches are compared with
+func (p *pageState) commit() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.pending {
+		return
+	}
+	p.validators = p.pendingValidators
+	p.hash = p.pendingHash
+	p.pending = false
+}
+
+// discard drops the pending fetch; the page is the same as the committed one
+func (p *pageState) discard() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = false
+}
+
+// pageHash returns the hash of the page without its volatile markup
+func pageHash(body []byte) string {
+	stable := volatileMarkup.ReplaceAll(body, nil)
+	stable = volatileText.ReplaceAll(stable, nil)
+
+	sum := sha256.Sum256(stable)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,85 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageHash(t *testing.T) {
+	page := `<html><head><meta name="csrf-token" content="%s"><script>var now = %d;</script></head><body>
+		<!-- rendered in %dms -->
+		<span class="clock">12:00:%02d</span>
+		<ins class="adsbygoogle" data-slot="%d"></ins>
+		<input type="hidden" name="token" value="%s">
+		<div class="deal"><a href="/deal/1">Deal 1</a> <span>%d분 전</span> <span class="votes">3</span></div>
+	</body></html>`
+
+	// Volatile markup does not change the hash
+	first := pageHash([]byte(fmt.Sprintf(page, "a", 1, 10, 1, 1, "x", 5)))
+	second := pageHash([]byte(fmt.Sprintf(page, "b", 2, 20, 2, 2, "y", 6)))
+	assert.Equal(t, first, second)
+
+	// The listing does
+	changed := pageHash([]byte(fmt.Sprintf(page, "a", 1, 10, 1, 1, "x", 5) + `<div class="deal">Deal 2</div>`))
+	assert.NotEqual(t, first, changed)
+}
+
+func TestFetchPageUnchanged(t *testing.T) {
+	requests := 0
+	listing := `<div class="deal"><a class="link" href="/deal/1">Deal 1</a></div>`
+	etag := `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		fmt.Fprintf(w, `<html><body><script>var t = %d;</script>%s</body></html>`, requests, listing)
+	}))
+	defer server.Close()
+
+	crawler := NewUnifiedCrawler(CrawlerConfig{
+		URL:      server.URL,
+		BaseURL:  server.URL,
+		Provider: "TestProvider",
+		Selectors: Selectors{
+			DealList: "div.deal",
+			Title:    "a.link",
+			Link:     "a.link",
+		},
+	}, nil)
+
+	deals, err := crawler.FetchDeals(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, deals, 1)
+
+	// Until the fetch is committed the page is parsed again
+	deals, err = crawler.FetchDeals(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, deals, 1)
+	crawler.CommitFetch()
+
+	// The server answers the ETag with 304
+	_, err = crawler.FetchDeals(context.Background())
+	assert.ErrorIs(t, err, ErrUnchanged)
+
+	// Without validators an identical body is recognized by its hash
+	etag = ""
+	_, err = crawler.FetchDeals(context.Background())
+	assert.ErrorIs(t, err, ErrUnchanged)
+	crawler.CommitFetch()
+
+	listing += `<div class="deal"><a class="link" href="/deal/2">Deal 2</a></div>`
+	deals, err = crawler.FetchDeals(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, deals, 2)
+	assert.Equal(t, 5, requests)
+}
@@ -1,12 +1,17 @@
 package crawler
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"strings"
 	"time"
 
+	"sjsage522/hotdealworker/internal/merchant"
 	"sjsage522/hotdealworker/logger"
 	"sjsage522/hotdealworker/services/cache"
+	"sjsage522/hotdealworker/services/redirect"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -17,7 +22,14 @@ type UnifiedCrawler struct {
 	Selectors    Selectors
 	ChromeDBAddr string
 	UseChrome    bool
-	fetchFunc    func() (io.Reader, error) // 크롤러별로 사용할 fetch 함수
+	fetchFunc    func(ctx context.Context, pageURL string) (io.Reader, error) // 크롤러별로 사용할 fetch 함수
+
+	// Detail reads the detail page of new deals; DetailLimiter is shared between crawlers
+	Detail        DetailSelectors
+	DetailLimiter Limiter
+
+	Merchants *merchant.Resolver
+	Redirects *redirect.Resolver
 }
 
 // NewUnifiedCrawler creates a new unified crawler
@@ -32,10 +44,28 @@ func NewUnifiedCrawler(config CrawlerConfig, cacheSvc cache.CacheService) *Unifi
 			Provider:    config.Provider,
 			IDExtractor: config.IDExtractor,
 			PriceRegex:  config.Selectors.PriceRegex,
+
+			ImageReferer:  config.ImageReferer,
+			ImageLimiter:  config.ImageLimiter,
+			ImageCacheTTL: config.ImageCacheTTL,
+			ImageMaxBytes: config.ImageMaxBytes,
+			Images:        config.Images,
+			Thumbnails:    config.Thumbnails,
+			Timeout:       config.Timeout,
+			Schedule:      config.Schedule,
+			Retry:         config.Retry,
+			PageURL:       config.PageURL,
+			PageOffset:    config.PageOffset,
+			MaxPages:      config.MaxPages,
+			firstPage:     &pageState{},
 		},
-		Selectors:    config.Selectors,
-		ChromeDBAddr: config.ChromeDBAddr,
-		UseChrome:    config.UseChrome,
+		Selectors:     config.Selectors,
+		ChromeDBAddr:  config.ChromeDBAddr,
+		UseChrome:     config.UseChrome,
+		Detail:        config.Detail,
+		DetailLimiter: config.DetailLimiter,
+		Merchants:     config.Merchants,
+		Redirects:     config.Redirects,
 	}
 
 	// 크롤러 타입에 따라 fetch 함수 설정
@@ -50,14 +80,91 @@ func NewUnifiedCrawler(config CrawlerConfig, cacheSvc cache.CacheService) *Unifi
 	return unified
 }
 
-// FetchDeals fetches deals using the unified approach
-func (c *UnifiedCrawler) FetchDeals() ([]HotDeal, error) {
+// FetchDeals fetches the deals on the first page
+func (c *UnifiedCrawler) FetchDeals(ctx context.Context) ([]HotDeal, error) {
+	return c.FetchPage(ctx, 1)
+}
+
+// FetchDealsUntil fetches the first page and keeps paging until a page lists
+// a deal for which seen returns true, so deals that scrolled off the first
+// page while the worker was down are not lost. It stops after MaxPages pages,
+// or at the first page that fails, keeping the deals fetched so far.
+func (c *UnifiedCrawler) FetchDealsUntil(ctx context.Context, seen func(key string) bool) ([]HotDeal, error) {
+	deals, err := c.FetchPage(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make(map[string]bool, len(deals))
+	for _, deal := range deals {
+		listed[deal.Key()] = true
+	}
+
+	last := deals
+	for page := 2; page <= c.MaxPages && c.PageURL != ""; page++ {
+		if len(last) == 0 || anySeen(last, seen) {
+			break
+		}
+
+		pageDeals, err := c.FetchPage(ctx, page)
+		if err != nil {
+			logger.Warn("[%s] Catch-up stopped at page %d: %v", c.Provider, page, err)
+			break
+		}
+
+		// Deals pushed down while paging show up again on the next page
+		var fresh []HotDeal
+		for _, deal := range pageDeals {
+			if !listed[deal.Key()] {
+				listed[deal.Key()] = true
+				fresh = append(fresh, deal)
+			}
+		}
+		deals = append(deals, fresh...)
+		last = fresh
+
+		logger.Info("[%s] Caught up page %d: %d deals", c.Provider, page, len(fresh))
+	}
+
+	return deals, nil
+}
+
+// anySeen reports whether seen returns true for any of the deals
+func anySeen(deals []HotDeal, seen func(key string) bool) bool {
+	for _, deal := range deals {
+		if seen(deal.Key()) {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchPage fetches the deals listed on the given page, starting at 1.
+// It returns ErrUnchanged when the first page is the same as last time.
+func (c *UnifiedCrawler) FetchPage(ctx context.Context, page int) ([]HotDeal, error) {
+	pageURL := c.PageURLFor(page)
+	if pageURL == "" {
+		return nil, fmt.Errorf("%s: page %d cannot be fetched without a page URL", c.Provider, page)
+	}
+
 	// Fetch the page using appropriate method
-	utf8Body, err := c.fetchFunc()
+	utf8Body, err := c.fetchFunc(ctx, pageURL)
 	if err != nil {
 		return nil, err
 	}
 
+	// A first page that is the same as last time has nothing new to process
+	if page == 1 && c.firstPage != nil {
+		body, err := io.ReadAll(utf8Body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to read page: %w", c.Provider, err)
+		}
+		if !c.firstPage.changed(body) {
+			return nil, ErrUnchanged
+		}
+		utf8Body = bytes.NewReader(body)
+	}
+
 	// Parse the HTML document
 	doc, err := c.createDocument(utf8Body)
 	if err != nil {
@@ -71,8 +178,11 @@ func (c *UnifiedCrawler) FetchDeals() ([]HotDeal, error) {
 	dealSelections := doc.Find(c.Selectors.DealList)
 	logger.Debug("[%s] Found %d potential deal elements", c.Provider, dealSelections.Length())
 
-	// Process deals
-	deals := c.processDeals(dealSelections, c.processDeal)
+	// Process deals. Relative posted times are resolved against the crawl time.
+	crawledAt := time.Now()
+	deals := c.processDeals(dealSelections, func(s *goquery.Selection) (*HotDeal, error) {
+		return c.processDeal(s, crawledAt)
+	})
 	logger.Debug("[%s] Successfully processed %d deals", c.Provider, len(deals))
 
 	return deals, nil
@@ -128,27 +238,26 @@ func (c *UnifiedCrawler) defaultLinkHandler(s *goquery.Selection) string {
 		return ""
 	}
 
-	return c.ResolveURL(strings.TrimSpace(link))
+	return c.ResolveLink(strings.TrimSpace(link))
 }
 
-// defaultThumbnailHandler is the default handler for extracting thumbnails
-func (c *UnifiedCrawler) defaultThumbnailHandler(s *goquery.Selection) (string, string) {
+// defaultThumbnailHandler is the default handler for extracting thumbnails.
+// It returns the thumbnail's URL; the image is downloaded by LoadThumbnail
+// once the deal is known to be published.
+func (c *UnifiedCrawler) defaultThumbnailHandler(s *goquery.Selection) string {
 	thumbSel := s.Find(c.Selectors.Thumbnail)
 
 	if thumbSel.Length() == 0 {
-		return "", ""
+		return ""
 	}
 
 	if src, exists := thumbSel.Attr("src"); exists {
-		thumbnail, thumbnailLink, _ := c.ProcessImage(src)
-		return thumbnail, thumbnailLink
+		return c.ResolveURL(src)
 	} else if style, exists := thumbSel.Attr("style"); exists && c.Selectors.ThumbRegex != "" {
-		thumbURL := c.ExtractURLFromStyle(style)
-		thumbnail, thumbnailLink, _ := c.ProcessImage(thumbURL)
-		return thumbnail, thumbnailLink
+		return c.ResolveURL(c.ExtractURLFromStyle(style))
 	}
 
-	return "", ""
+	return ""
 }
 
 // defaultPostedAtHandler is the default handler for extracting posted time
@@ -171,7 +280,7 @@ func (c *UnifiedCrawler) defaultCategoryHandler(s *goquery.Selection) string {
 }
 
 // processDeal processes a single deal based on the configuration
-func (c *UnifiedCrawler) processDeal(s *goquery.Selection) (*HotDeal, error) {
+func (c *UnifiedCrawler) processDeal(s *goquery.Selection, crawledAt time.Time) (*HotDeal, error) {
 	// Skip if the element has a class to filter out
 	if c.Selectors.ClassFilter != "" && s.HasClass(c.Selectors.ClassFilter) {
 		return nil, nil
@@ -244,7 +353,7 @@ func (c *UnifiedCrawler) processDeal(s *goquery.Selection) (*HotDeal, error) {
 			}
 		}
 	} else if c.Selectors.Thumbnail != "" {
-		thumbnail, thumbnailLink = c.defaultThumbnailHandler(s)
+		thumbnailLink = c.defaultThumbnailHandler(s)
 	}
 
 	// Extract posted time
@@ -263,5 +372,45 @@ func (c *UnifiedCrawler) processDeal(s *goquery.Selection) (*HotDeal, error) {
 	}
 	category = classifyCategory(category)
 
-	return c.CreateDeal(id, title, link, price, thumbnail, thumbnailLink, postedAt, category), nil
+	deal := c.CreateDeal(id, title, link, price, thumbnail, thumbnailLink, postedAt, category)
+	deal.Status = c.detectStatus(s, title)
+	// The link points at the board post; the shop link is only known from the detail page
+	if c.Merchants != nil {
+		deal.Merchant = c.Merchants.FromTitle(title)
+	}
+	deal.SetPostedAt(postedAt, crawledAt)
+
+	// Engagement counts are optional and left nil when the board does not show them
+	deal.Votes = c.extractCount(s, c.Selectors.Votes, c.Selectors.VoteHandlers)
+	deal.Comments = c.extractCount(s, c.Selectors.Comments, c.Selectors.CommentHandlers)
+	deal.Views = c.extractCount(s, c.Selectors.Views, c.Selectors.ViewHandlers)
+
+	// Parse the raw price into typed fields.
+	// A shipping fee is taken from the price, then the shipping handlers, then the title.
+	priceInfo := ParsePrice(price)
+	if priceInfo.ShippingFee == nil && len(c.Selectors.ShippingHandlers) > 0 {
+		priceInfo.ShippingFee = ParseShippingFee(c.applyHandlers(s, c.Selectors.ShippingHandlers))
+	}
+	if priceInfo.ShippingFee == nil {
+		priceInfo.ShippingFee = ExtractShippingFee(title)
+	}
+	deal.SetPriceInfo(priceInfo)
+
+	return deal, nil
+}
+
+// detectStatus determines the lifecycle status of a deal row.
+// Custom status handlers win, then the ended class, then markers in the title.
+func (c *UnifiedCrawler) detectStatus(s *goquery.Selection, title string) string {
+	if len(c.Selectors.StatusHandlers) > 0 {
+		if status := c.applyHandlers(s, c.Selectors.StatusHandlers); status != "" {
+			return status
+		}
+	}
+
+	if c.Selectors.EndedClass != "" && s.HasClass(c.Selectors.EndedClass) {
+		return StatusEnded
+	}
+
+	return DetectStatusFromTitle(title)
 }
@@ -1,9 +1,13 @@
 package crawler
 
 import (
+	"context"
 	"io"
 	"strings"
 	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/internal/merchant"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/stretchr/testify/assert"
@@ -29,13 +33,14 @@ func TestUnifiedCrawler(t *testing.T) {
 			DealList:      "div.deal",
 			Title:         "div.title",
 			Link:          "a.link",
+			Thumbnail:     "img.thumb",
 			Price:         "div.price",
 			PriceHandlers: []ElementHandler{priceHandler},
 		},
 	}, mockCache)
 
 	// Mock the fetch function directly for testing
-	crawler.fetchFunc = func() (io.Reader, error) {
+	crawler.fetchFunc = func(context.Context, string) (io.Reader, error) {
 		html := `<html><body>
 			<div class="deal">
 				<div class="title">Deal 1</div>
@@ -44,7 +49,8 @@ func TestUnifiedCrawler(t *testing.T) {
 			</div>
 			<div class="deal">
 				<div class="title">Deal 2</div>
-				<a class="link" href="https://example.com/deal/2">Link 2</a>
+				<a class="link" href="https://example.com/deal/2?utm_source=feed&fbclid=abc">Link 2</a>
+				<img class="thumb" src="https://cdn.example.com/2.jpg?n_media=27&spm=w300">
 				<div class="price">$20</div>
 			</div>
 		</body></html>`
@@ -66,7 +72,7 @@ func TestUnifiedCrawler(t *testing.T) {
 	assert.Equal(t, "$15.99", result, "Price handler should extract price correctly")
 
 	// Test 2: 실제 FetchDeals 함수를 호출하여 통합 테스트
-	deals, err := crawler.FetchDeals()
+	deals, err := crawler.FetchDeals(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(deals), "Should find 2 deals")
 
@@ -93,10 +99,120 @@ func TestUnifiedCrawler(t *testing.T) {
 	if deal2 != nil {
 		assert.Equal(t, "Deal 2", deal2.Title, "Title should be extracted correctly")
 		assert.Equal(t, "https://example.com/deal/2", deal2.Link, "Link should be extracted correctly")
+		assert.Equal(t, "https://cdn.example.com/2.jpg?n_media=27&spm=w300", deal2.ThumbnailLink, "Image URLs should be kept as they are")
 		assert.Equal(t, "$20", deal2.Price, "Price should be properly extracted")
 	}
 }
 
+// TestFetchDealsUntil tests paging back until a seen deal or the page cap
+func TestFetchDealsUntil(t *testing.T) {
+	pages := map[string]string{
+		"https://example.com/list":        `<div class="deal"><a href="/6">Deal 6</a></div><div class="deal"><a href="/5">Deal 5</a></div>`,
+		"https://example.com/list?page=2": `<div class="deal"><a href="/5">Deal 5</a></div><div class="deal"><a href="/4">Deal 4</a></div>`,
+		"https://example.com/list?page=3": `<div class="deal"><a href="/3">Deal 3</a></div><div class="deal"><a href="/2">Deal 2</a></div>`,
+		"https://example.com/list?page=4": `<div class="deal"><a href="/1">Deal 1</a></div>`,
+	}
+
+	newCrawler := func(pageURL string) (*UnifiedCrawler, *[]string) {
+		crawler := NewUnifiedCrawler(CrawlerConfig{
+			URL:      "https://example.com/list",
+			BaseURL:  "https://example.com",
+			Provider: "TestProvider",
+			PageURL:  pageURL,
+			MaxPages: 3,
+			Selectors: Selectors{
+				DealList: "div.deal",
+				Title:    "a",
+				Link:     "a",
+			},
+			IDExtractor: func(link string) (string, error) {
+				return strings.TrimPrefix(link, "https://example.com/"), nil
+			},
+		}, nil)
+
+		var fetched []string
+		crawler.fetchFunc = func(_ context.Context, pageURL string) (io.Reader, error) {
+			fetched = append(fetched, pageURL)
+			return strings.NewReader(pages[pageURL]), nil
+		}
+		return crawler, &fetched
+	}
+
+	ids := func(deals []HotDeal) []string {
+		var result []string
+		for _, deal := range deals {
+			result = append(result, deal.Id)
+		}
+		return result
+	}
+
+	// Paging stops at the page that lists a seen deal; deals pushed down are not repeated
+	crawler, fetched := newCrawler("https://example.com/list?page={page}")
+	deals, err := crawler.FetchDealsUntil(context.Background(), func(key string) bool { return key == "3" })
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"6", "5", "4", "3", "2"}, ids(deals))
+	assert.Len(t, *fetched, 3)
+
+	// The page cap stops paging when no seen deal is found
+	crawler, fetched = newCrawler("https://example.com/list?page={page}")
+	deals, err = crawler.FetchDealsUntil(context.Background(), func(key string) bool { return false })
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"6", "5", "4", "3", "2"}, ids(deals))
+	assert.Len(t, *fetched, 3)
+
+	// Nothing beyond the first page is fetched when it already lists a seen deal
+	crawler, fetched = newCrawler("https://example.com/list?page={page}")
+	deals, err = crawler.FetchDealsUntil(context.Background(), func(key string) bool { return key == "5" })
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"6", "5"}, ids(deals))
+	assert.Len(t, *fetched, 1)
+
+	// Without a page URL only the first page is fetched
+	crawler, fetched = newCrawler("")
+	deals, err = crawler.FetchDealsUntil(context.Background(), func(key string) bool { return false })
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"6", "5"}, ids(deals))
+	assert.Len(t, *fetched, 1)
+
+	_, err = crawler.FetchPage(context.Background(), 2)
+	assert.Error(t, err)
+}
+
+// TestMerchantFromTitle tests identifying the merchant of listed deals
+func TestMerchantFromTitle(t *testing.T) {
+	crawler := NewUnifiedCrawler(CrawlerConfig{
+		URL:       "https://example.com",
+		BaseURL:   "https://example.com",
+		Provider:  "TestProvider",
+		Merchants: merchant.Default(),
+		Selectors: Selectors{
+			DealList: "div.deal",
+			Title:    "a",
+			Link:     "a",
+		},
+	}, nil)
+
+	crawler.fetchFunc = func(context.Context, string) (io.Reader, error) {
+		html := `<html><body>
+			<div class="deal"><a href="/1">[쿠팡] 신라면 40봉</a></div>
+			<div class="deal"><a href="/2">[11번가] 삼다수 2L 12병</a></div>
+			<div class="deal"><a href="https://www.coupang.com/vp/products/4">햇반 24개</a></div>
+			<div class="deal"><a href="/3">햇반 36개</a></div>
+		</body></html>`
+		return strings.NewReader(html), nil
+	}
+
+	deals, err := crawler.FetchDeals(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, deals, 4) {
+		assert.Equal(t, "coupang", deals[0].Merchant)
+		assert.Equal(t, "11st", deals[1].Merchant)
+		assert.Equal(t, "", deals[2].Merchant)
+		// The listed link is the board post, so it never names the merchant
+		assert.Equal(t, "", deals[3].Merchant)
+	}
+}
+
 // TestCrawlerWithPriceRegex tests a crawler that extracts price using regex
 func TestCrawlerWithPriceRegex(t *testing.T) {
 	// Create a test crawler with price regex
@@ -116,7 +232,7 @@ func TestCrawlerWithPriceRegex(t *testing.T) {
 	}, mockCache)
 
 	// Mock the fetch function directly for testing
-	crawler.fetchFunc = func() (io.Reader, error) {
+	crawler.fetchFunc = func(context.Context, string) (io.Reader, error) {
 		html := `<html><body>
 			<div class="deal">
 				<div class="title">Deal 1 $99</div>
@@ -141,7 +257,7 @@ func TestCrawlerWithPriceRegex(t *testing.T) {
 	assert.Equal(t, "199", price2, "Price should be extracted from the title")
 
 	// 2. 실제 크롤링 결과 확인 - 통합 테스트
-	deals, err := crawler.FetchDeals()
+	deals, err := crawler.FetchDeals(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(deals))
 
@@ -246,9 +362,10 @@ func TestDefaultHandlers(t *testing.T) {
 		BaseURL:  "https://example.com",
 		Provider: "TestProvider",
 		Selectors: Selectors{
-			Title:    "div.title",
-			Link:     "a.link",
-			PostedAt: "div.posted-at",
+			Title:     "div.title",
+			Link:      "a.link",
+			PostedAt:  "div.posted-at",
+			Thumbnail: "img.thumb",
 		},
 	}, nil)
 
@@ -258,6 +375,7 @@ func TestDefaultHandlers(t *testing.T) {
 			<div class="title" title="Item Title Attribute">Item Title</div>
 			<a class="link" href="/relative/path">Link Text</a>
 			<div class="posted-at">2023-01-01</div>
+			<img class="thumb" src="/thumb/1.jpg?utm_source=list">
 		</div>
 	</body></html>`
 
@@ -279,6 +397,14 @@ func TestDefaultHandlers(t *testing.T) {
 	// Test posted at handler - 실제 defaultPostedAtHandler 로직 테스트
 	postedAt := crawler.defaultPostedAtHandler(item)
 	assert.Equal(t, "2023-01-01", postedAt)
+
+	// The thumbnail handler only resolves the URL, keeping its parameters since
+	// image servers may need them; the image is downloaded after dedupe
+	assert.Equal(t, "https://example.com/thumb/1.jpg?utm_source=list", crawler.defaultThumbnailHandler(item))
+	deal, err := crawler.processDeal(item, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/thumb/1.jpg?utm_source=list", deal.ThumbnailLink)
+	assert.Empty(t, deal.Thumbnail)
 }
 
 // TestCustomHandlers tests the custom handlers of the unified crawler
@@ -304,7 +430,7 @@ func TestCustomHandlers(t *testing.T) {
 	}, nil)
 
 	// Mock the fetch function for testing
-	crawler.fetchFunc = func() (io.Reader, error) {
+	crawler.fetchFunc = func(context.Context, string) (io.Reader, error) {
 		html := `<html><body>
 			<div class="item">
 				<div class="title">Original Title</div>
@@ -364,3 +490,62 @@ func TestFetchWithOptions(t *testing.T) {
 	// Chrome 페처 적용 확인
 	assert.NotNil(t, chromeCrawler.fetchFunc)
 }
+
+// TestStatusDetection tests that ended rows are kept and marked instead of dropped
+func TestStatusDetection(t *testing.T) {
+	endedHandler := func(s *goquery.Selection) string {
+		if s.Find("a.link.ended").Length() > 0 {
+			return StatusEnded
+		}
+		return ""
+	}
+
+	crawler := NewUnifiedCrawler(CrawlerConfig{
+		URL:      "https://example.com",
+		BaseURL:  "https://example.com",
+		Provider: "TestProvider",
+		Selectors: Selectors{
+			DealList:       "div.deal",
+			Title:          "div.title",
+			Link:           "a.link",
+			ClassFilter:    "notice",
+			EndedClass:     "deal-ended",
+			StatusHandlers: []ElementHandler{endedHandler},
+		},
+	}, nil)
+
+	crawler.fetchFunc = func(context.Context, string) (io.Reader, error) {
+		html := `<html><body>
+			<div class="deal notice">
+				<div class="title">Notice</div>
+				<a class="link" href="/notice">Notice</a>
+			</div>
+			<div class="deal">
+				<div class="title">Active Deal</div>
+				<a class="link" href="/1">Link</a>
+			</div>
+			<div class="deal deal-ended">
+				<div class="title">Ended Deal</div>
+				<a class="link" href="/2">Link</a>
+			</div>
+			<div class="deal">
+				<div class="title">Handler Ended Deal</div>
+				<a class="link ended" href="/3">Link</a>
+			</div>
+			<div class="deal">
+				<div class="title">[품절] Sold Out Deal</div>
+				<a class="link" href="/4">Link</a>
+			</div>
+		</body></html>`
+		return strings.NewReader(html), nil
+	}
+
+	deals, err := crawler.FetchDeals(context.Background())
+	assert.NoError(t, err)
+	if assert.Equal(t, 4, len(deals)) {
+		assert.Equal(t, StatusActive, deals[0].Status)
+		assert.Equal(t, StatusEnded, deals[1].Status)
+		assert.Equal(t, StatusEnded, deals[2].Status)
+		assert.Equal(t, StatusSoldOut, deals[3].Status)
+	}
+}
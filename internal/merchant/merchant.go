@@ -0,0 +1,173 @@
+package merchant
+
+import (
+	_ "embed"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// builtInRules holds the built-in merchant rules
+//
+//go:embed merchants.yaml
+var builtInRules []byte
+
+// titlePrefixRegex matches a bracketed prefix such as "[쿠팡]", "(11번가)" or "【G마켓】"
+var titlePrefixRegex = regexp.MustCompile(`^\s*[\[\(【]\s*([^\]\)】]+?)\s*[\]\)】]`)
+
+// Rule maps outbound link domains and title prefixes to a merchant code
+type Rule struct {
+	// Code is the canonical merchant code published with the deal
+	Code string `yaml:"code"`
+	Name string `yaml:"name"`
+	// Domains match the link's host and its subdomains
+	Domains []string `yaml:"domains"`
+	// Aliases match the bracketed title prefix, ignoring case and spaces
+	Aliases []string `yaml:"aliases"`
+}
+
+// Resolver identifies the merchant of a deal from its outbound link or title
+type Resolver struct {
+	domains map[string]string // domain -> code
+	aliases map[string]string // normalized alias -> code
+}
+
+// Default returns a resolver with the built-in rules
+func Default() *Resolver {
+	rules, err := ParseRules(builtInRules)
+	if err != nil {
+		panic(fmt.Sprintf("built-in merchant rules: %v", err))
+	}
+	return NewResolver(rules)
+}
+
+// LoadData returns a resolver with the built-in rules extended by the rules
+// in data. A rule in data replaces the built-in rule with the same code.
+func LoadData(data []byte) (*Resolver, error) {
+	rules, err := ParseRules(builtInRules)
+	if err != nil {
+		return nil, fmt.Errorf("built-in merchant rules: %w", err)
+	}
+
+	extra, err := ParseRules(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range extra {
+		replaced := false
+		for i := range rules {
+			if rules[i].Code == rule.Code {
+				rules[i] = rule
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			rules = append(rules, rule)
+		}
+	}
+
+	return NewResolver(rules), nil
+}
+
+// ParseRules parses and validates a YAML list of merchant rules
+func ParseRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+
+	for i, rule := range rules {
+		if rule.Code == "" {
+			return nil, fmt.Errorf("rule %d: code is required", i)
+		}
+		if len(rule.Domains) == 0 && len(rule.Aliases) == 0 {
+			return nil, fmt.Errorf("%s: domains or aliases are required", rule.Code)
+		}
+	}
+	return rules, nil
+}
+
+// NewResolver creates a resolver from the rules. Earlier rules win when two
+// rules claim the same domain or alias.
+func NewResolver(rules []Rule) *Resolver {
+	r := &Resolver{
+		domains: make(map[string]string),
+		aliases: make(map[string]string),
+	}
+
+	for _, rule := range rules {
+		for _, domain := range rule.Domains {
+			domain = strings.ToLower(strings.TrimPrefix(domain, "www."))
+			if _, ok := r.domains[domain]; !ok {
+				r.domains[domain] = rule.Code
+			}
+		}
+		for _, alias := range rule.Aliases {
+			alias = normalizeAlias(alias)
+			if _, ok := r.aliases[alias]; !ok {
+				r.aliases[alias] = rule.Code
+			}
+		}
+	}
+
+	return r
+}
+
+// FromURL returns the merchant code for the host of the link
+func (r *Resolver) FromURL(link string) string {
+	if link == "" {
+		return ""
+	}
+
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+
+	// Match the host, then each parent domain: "m.shop.coupang.com", "shop.coupang.com", "coupang.com"
+	for host != "" {
+		if code, ok := r.domains[host]; ok {
+			return code
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return ""
+}
+
+// FromTitle returns the merchant code for the bracketed prefix of a title,
+// e.g. "[쿠팡] 신라면". Prefixes listing several names, such as "[G마켓/옥션]",
+// yield the first name that is recognized.
+func (r *Resolver) FromTitle(title string) string {
+	m := titlePrefixRegex.FindStringSubmatch(title)
+	if m == nil {
+		return ""
+	}
+
+	if code, ok := r.aliases[normalizeAlias(m[1])]; ok {
+		return code
+	}
+
+	for _, part := range strings.FieldsFunc(m[1], func(c rune) bool {
+		return strings.ContainsRune("/|,·", c)
+	}) {
+		if code, ok := r.aliases[normalizeAlias(part)]; ok {
+			return code
+		}
+	}
+	return ""
+}
+
+// normalizeAlias lowercases the name and drops its spaces
+func normalizeAlias(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
@@ -0,0 +1,101 @@
+package merchant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromURL(t *testing.T) {
+	r := Default()
+
+	testCases := map[string]string{
+		"https://www.coupang.com/vp/products/123":        "coupang",
+		"https://link.coupang.com/a/abc":                 "coupang",
+		"https://www.11st.co.kr/products/123":            "11st",
+		"http://item.gmarket.co.kr/Item?goodscode=1":     "gmarket",
+		"https://smartstore.naver.com/shop/products/1":   "naver_smartstore",
+		"https://ko.aliexpress.com/item/1005.html":       "aliexpress",
+		"https://s.click.aliexpress.com/e/_abc":          "aliexpress",
+		"https://www.amazon.co.jp/dp/B0000":              "amazon",
+		"https://amzn.to/3abc":                           "amazon",
+		"https://notcoupang.com/item":                    "",
+		"https://www.ppomppu.co.kr/zboard/view.php?no=1": "",
+		"not a link": "",
+	}
+
+	for link, expect := range testCases {
+		assert.Equal(t, expect, r.FromURL(link), link)
+	}
+}
+
+func TestFromTitle(t *testing.T) {
+	r := Default()
+
+	testCases := map[string]string{
+		"[쿠팡] 신라면 40봉 (19,900원/무료)": "coupang",
+		"[11번가]삼다수 2L 12병":          "11st",
+		"(G마켓) 햇반 36개":              "gmarket",
+		"【AliExpress】 USB 허브":       "aliexpress",
+		"[네이버 스마트스토어] 사과 5kg":       "naver_smartstore",
+		"[G마켓/옥션] 스팸 10캔":           "gmarket",
+		"[기타/옥션] 스팸 10캔":            "auction",
+		"[아마존] 킨들":                  "amazon",
+		"신라면 [쿠팡]":                  "",
+		"[종료] 신라면":                  "",
+		"[알수없는몰] 신라면":               "",
+	}
+
+	for title, expect := range testCases {
+		assert.Equal(t, expect, r.FromTitle(title), title)
+	}
+}
+
+func TestLoadData(t *testing.T) {
+	rules := `
+- code: coupang
+  domains: [coupang.com]
+  aliases: [쿠팡, 로켓]
+- code: oliveyoung
+  name: 올리브영
+  domains: [oliveyoung.co.kr]
+  aliases: [올리브영, 올영]
+`
+	r, err := LoadData([]byte(rules))
+	assert.NoError(t, err)
+
+	// New rules are added
+	assert.Equal(t, "oliveyoung", r.FromURL("https://www.oliveyoung.co.kr/store/goods"))
+	assert.Equal(t, "oliveyoung", r.FromTitle("[올영] 선크림"))
+
+	// A rule with the same code replaces the built-in one
+	assert.Equal(t, "coupang", r.FromTitle("[로켓] 신라면"))
+	assert.Equal(t, "", r.FromURL("https://coupa.ng/abc"))
+
+	// Built-in rules are kept
+	assert.Equal(t, "11st", r.FromTitle("[11번가] 삼다수"))
+
+	// An empty file only has the built-in rules
+	r, err = LoadData(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "coupang", r.FromURL("https://coupa.ng/abc"))
+
+	_, err = LoadData([]byte("- code: [x"))
+	assert.Error(t, err)
+}
+
+func TestParseRulesInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"missing code":     "- domains: [example.com]",
+		"missing matchers": "- code: example",
+		"malformed yaml":   "- code: [x",
+		"not a list":       "code: example",
+	}
+
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseRules([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package urlcanon
+
+import (
+	"net/url"
+	"strings"
+)
+
+// boardParams identify a post on the community boards and are never stripped
+// from links on the board hosts, since the ID extractors of the site
+// definitions read them from the link
+var boardParams = map[string]bool{
+	"no":           true,
+	"id":           true,
+	"wr_id":        true,
+	"bo_table":     true,
+	"mid":          true,
+	"document_srl": true,
+}
+
+// trackingParams only track the visitor and are stripped from every link
+var trackingParams = map[string]bool{
+	"fbclid":     true,
+	"gclid":      true,
+	"dclid":      true,
+	"gbraid":     true,
+	"wbraid":     true,
+	"msclkid":    true,
+	"yclid":      true,
+	"igshid":     true,
+	"twclid":     true,
+	"ttclid":     true,
+	"mc_cid":     true,
+	"mc_eid":     true,
+	"_ga":        true,
+	"_gl":        true,
+	"spm":        true,
+	"scm":        true,
+	"pvid":       true,
+	"napm":       true,
+	"affiliate":  true,
+	"jsessionid": true,
+	"phpsessid":  true,
+	"sessionid":  true,
+}
+
+// trackingPrefixes strip every parameter whose name starts with them
+var trackingPrefixes = []string{"utm_", "aff_", "n_"}
+
+// keepRules list the only query parameters kept on the links of a domain and
+// its subdomains. Shops put their affiliate and referral codes in many
+// parameters, so for them it is safer to name what identifies the product.
+// Names are lowercase.
+var keepRules = map[string][]string{
+	"coupang.com":          {"itemid", "vendoritemid", "pagekey"},
+	"11st.co.kr":           {"prdno"},
+	"gmarket.co.kr":        {"goodscode"},
+	"auction.co.kr":        {"itemno"},
+	"smartstore.naver.com": {},
+	"brand.naver.com":      {},
+	"aliexpress.com":       {},
+	"amazon.com":           {"k", "node"},
+	"amazon.co.jp":         {"k", "node"},
+	"ssg.com":              {"itemid", "siteno"},
+	"lotteon.com":          {"sitmno"},
+}
+
+// Canonicalize returns the link without its tracking, affiliate and session
+// parameters. The kept parameters stay in their order and encoding, and the
+// fragment is left alone. Links that cannot be parsed are returned as they are.
+func Canonicalize(link string) string {
+	return CanonicalizePost(link)
+}
+
+// CanonicalizePost is like Canonicalize but keeps the parameters that identify
+// a board post when the link is on one of the board hosts or their subdomains
+func CanonicalizePost(link string, boardHosts ...string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+	onBoard := isBoardHost(u.Hostname(), boardHosts)
+
+	changed := false
+
+	// Session IDs appended to the path, e.g. "/item;jsessionid=ABC"
+	if i := strings.Index(strings.ToLower(u.Path), ";jsessionid="); i >= 0 {
+		u.Path = u.Path[:i]
+		u.RawPath = ""
+		changed = true
+	}
+
+	if u.RawQuery != "" {
+		keep, hasRule := keepRule(u.Hostname())
+
+		parts := strings.Split(u.RawQuery, "&")
+		kept := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if part == "" {
+				continue
+			}
+			key, _, _ := strings.Cut(part, "=")
+			if name, err := url.QueryUnescape(key); err == nil {
+				key = name
+			}
+			if keepParam(strings.ToLower(key), keep, hasRule, onBoard) {
+				kept = append(kept, part)
+			}
+		}
+
+		if len(kept) != len(parts) {
+			u.RawQuery = strings.Join(kept, "&")
+			changed = true
+		}
+	}
+
+	if !changed {
+		return link
+	}
+	return u.String()
+}
+
+// keepRule returns the keep list for the host or its closest parent domain
+func keepRule(host string) ([]string, bool) {
+	host = strings.ToLower(host)
+	for host != "" {
+		if keep, ok := keepRules[host]; ok {
+			return keep, true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return nil, false
+}
+
+// isBoardHost reports whether the host is one of the board hosts or their subdomains
+func isBoardHost(host string, boardHosts []string) bool {
+	host = strings.ToLower(host)
+	for _, board := range boardHosts {
+		board = strings.ToLower(strings.TrimPrefix(board, "www."))
+		if board != "" && (host == board || strings.HasSuffix(host, "."+board)) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepParam reports whether the lowercase parameter name survives canonicalization
+func keepParam(key string, keep []string, hasRule, onBoard bool) bool {
+	if onBoard && boardParams[key] {
+		return true
+	}
+
+	if hasRule {
+		for _, name := range keep {
+			if name == key {
+				return true
+			}
+		}
+		return false
+	}
+
+	if trackingParams[key] {
+		return false
+	}
+	for _, prefix := range trackingPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package urlcanon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalize(t *testing.T) {
+	testCases := map[string]string{
+		// Tracking parameters are stripped everywhere
+		"https://example.com/a?utm_source=x&utm_medium=y&q=1":  "https://example.com/a?q=1",
+		"https://example.com/a?fbclid=abc":                     "https://example.com/a",
+		"https://example.com/a?q=%ED%95%9C&gclid=1&b=2":        "https://example.com/a?q=%ED%95%9C&b=2",
+		"https://example.com/a;jsessionid=ABC123?q=1":          "https://example.com/a?q=1",
+		"https://example.com/a?PHPSESSID=abc&page=2#comment-1": "https://example.com/a?page=2#comment-1",
+
+		// Shop domains keep only the parameters that identify the product
+		"https://www.coupang.com/vp/products/1?itemId=2&vendorItemId=3&lptag=AF1&traceid=x": "https://www.coupang.com/vp/products/1?itemId=2&vendorItemId=3",
+		"https://link.coupang.com/re/AFFSDP?lptag=AF1&subid=me&pageKey=1":                   "https://link.coupang.com/re/AFFSDP?pageKey=1",
+		"http://item.gmarket.co.kr/Item?goodscode=1&jaehuid=200&pos_shop_cd=SH":             "http://item.gmarket.co.kr/Item?goodscode=1",
+		"https://smartstore.naver.com/shop/products/1?NaPm=ct%3Dabc&site_preference=device": "https://smartstore.naver.com/shop/products/1",
+		"https://www.amazon.co.jp/dp/B0000?tag=aff-22&linkCode=ll1&th=1":                    "https://www.amazon.co.jp/dp/B0000",
+		"https://www.coupang.com/vp/products/1?itemId=2&id=tracker&no=5":                    "https://www.coupang.com/vp/products/1?itemId=2",
+
+		// Links that need no change are returned as they are
+		"https://example.com/a?b=1&a=2": "https://example.com/a?b=1&a=2",
+		"/relative?utm_source=x":        "/relative?utm_source=x",
+		"":                              "",
+	}
+
+	for link, expect := range testCases {
+		assert.Equal(t, expect, Canonicalize(link), link)
+	}
+}
+
+func TestCanonicalizePost(t *testing.T) {
+	boards := []string{"www.ppomppu.co.kr", "dealbada.com", "eomisae.co.kr", "missycoupons.com", "coupang.com"}
+
+	testCases := map[string]string{
+		// Board post keys are never touched on the board hosts
+		"https://www.ppomppu.co.kr/zboard/view.php?id=ppomppu&no=123&utm_source=x": "https://www.ppomppu.co.kr/zboard/view.php?id=ppomppu&no=123",
+		"https://m.ppomppu.co.kr/new/bbs_view.php?id=ppomppu&no=123&fbclid=a":      "https://m.ppomppu.co.kr/new/bbs_view.php?id=ppomppu&no=123",
+		"https://www.dealbada.com/bbs/board.php?bo_table=deal&wr_id=1":             "https://www.dealbada.com/bbs/board.php?bo_table=deal&wr_id=1",
+		"https://eomisae.co.kr/index.php?mid=os&document_srl=9&fbclid=a":           "https://eomisae.co.kr/index.php?mid=os&document_srl=9",
+		"https://www.missycoupons.com/zero/board.php#id=hotdeals&no=1":             "https://www.missycoupons.com/zero/board.php#id=hotdeals&no=1",
+
+		// A board host with a keep rule keeps the post keys too
+		"https://www.coupang.com/np/board?id=7&lptag=AF1": "https://www.coupang.com/np/board?id=7",
+
+		// Other hosts follow their own rules
+		"https://link.11st.co.kr/p?prdNo=1&id=tracker": "https://link.11st.co.kr/p?prdNo=1",
+	}
+
+	for link, expect := range testCases {
+		assert.Equal(t, expect, CanonicalizePost(link, boards...), link)
+	}
+}
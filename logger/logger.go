@@ -195,6 +195,14 @@ func ForCache() *Logger {
 	return Default.WithField("component", "cache")
 }
 
+// ForComponent creates a logger for the named component
+func ForComponent(component string) *Logger {
+	if Default == nil {
+		Init()
+	}
+	return Default.WithField("component", component)
+}
+
 // LogError is a convenience method for logging errors with context
 func LogError(component string, err error, format string, v ...interface{}) {
 	if Default == nil {
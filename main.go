@@ -2,16 +2,22 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"sjsage522/hotdealworker/config"
+	"sjsage522/hotdealworker/helpers"
 	"sjsage522/hotdealworker/internal/crawler"
 	"sjsage522/hotdealworker/logger"
 	"sjsage522/hotdealworker/services/cache"
+	"sjsage522/hotdealworker/services/dedup"
+	"sjsage522/hotdealworker/services/hotness"
 	"sjsage522/hotdealworker/services/publisher"
+	"sjsage522/hotdealworker/services/store"
 	"sjsage522/hotdealworker/services/worker"
 
 	"github.com/joho/godotenv"
@@ -31,6 +37,9 @@ func main() {
 		log.Fatal().Err(err).Msg("Invalid configuration")
 	}
 
+	// Every outbound request is paced per host and capped across hosts
+	helpers.SetHostLimiter(helpers.NewHostLimiter(cfg.FetchConcurrency, cfg.FetchHostRate, cfg.FetchHostBurst, cfg.FetchHostRates))
+
 	if err := crawler.InitializeProxyManager(); err != nil {
 		log.Warn().Err(err).Msg("Failed to initialize proxy manager")
 	}
@@ -40,6 +49,7 @@ func main() {
 	log.Info().
 		Str("environment", cfg.Environment).
 		Dur("crawl_interval", cfg.CrawlInterval).
+		Dur("dedup_ttl", cfg.DedupTTL).
 		Msg("Starting application")
 
 	// Set up context with cancellation
@@ -58,7 +68,11 @@ func main() {
 	defer services.Cleanup()
 
 	// Create crawlers
-	crawlers := crawler.CreateCrawlers(&cfg, services.Cache)
+	reloader, err := crawler.NewSiteReloader(&cfg, services.Cache)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load site definitions")
+	}
+	crawlers := reloader.Crawlers()
 	if len(crawlers) == 0 {
 		log.Fatal().Msg("No crawlers were created")
 	}
@@ -72,9 +86,22 @@ func main() {
 		ctx,
 		crawlers,
 		services.Publisher,
+		dedup.NewSeenSet(services.Cache, cfg.DedupTTL),
+		services.History,
+		hotness.NewTracker(services.Cache, cfg.DedupTTL, cfg.HotnessThreshold),
 		cfg.CrawlInterval,
 	)
 
+	if cfg.MetricsAddr != "" {
+		startMetrics(cfg.MetricsAddr, w)
+	}
+
+	// Reload site definitions on SIGHUP or when the sites directory changes.
+	// New crawlers take effect from the next cycle.
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go reloader.Watch(ctx, cfg.SitesReloadInterval, hupChan, w.SetCrawlers)
+
 	// Start worker in a goroutine
 	workerDone := make(chan error, 1)
 	go func() {
@@ -106,6 +133,7 @@ func main() {
 type Services struct {
 	Cache     cache.CacheService
 	Publisher publisher.Publisher
+	History   store.Store
 }
 
 // Cleanup cleans up all services
@@ -113,6 +141,28 @@ func (s *Services) Cleanup() {
 	if s.Publisher != nil {
 		s.Publisher.Close()
 	}
+	if s.History != nil {
+		s.History.Close()
+	}
+}
+
+// startMetrics serves the runtime metrics, including each provider's current
+// crawl interval in seconds, as JSON at /debug/vars
+func startMetrics(addr string, w *worker.Worker) {
+	expvar.Publish("crawl_interval_seconds", expvar.Func(func() any {
+		seconds := make(map[string]float64)
+		for provider, interval := range w.Intervals() {
+			seconds[provider] = interval.Seconds()
+		}
+		return seconds
+	}))
+
+	go func() {
+		logger.Info("Serving metrics at %s/debug/vars", addr)
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			logger.Error("Metrics server stopped: %v", err)
+		}
+	}()
 }
 
 // initializeServices initializes all required services
@@ -145,5 +195,16 @@ func initializeServices(ctx context.Context, cfg *config.Config) (*Services, err
 	logger.Info("Connected to Redis at %s (DB: %d, Stream: %s)",
 		cfg.RedisAddr, cfg.RedisDB, cfg.RedisStream)
 
+	// Initialize history store (optional)
+	if cfg.HistoryDBPath != "" {
+		historyStore, err := store.NewSQLiteStore(cfg.HistoryDBPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open history store: %w", err)
+		}
+		services.History = historyStore
+
+		logger.Info("Opened deal history store at %s", cfg.HistoryDBPath)
+	}
+
 	return services, nil
 }
@@ -15,6 +15,8 @@ const (
 	ErrorTypeParsing ErrorType = "parsing"
 	// ErrorTypeRateLimit represents rate limiting errors
 	ErrorTypeRateLimit ErrorType = "rate_limit"
+	// ErrorTypeRefused represents requests the server refused, such as 403 or 404
+	ErrorTypeRefused ErrorType = "refused"
 	// ErrorTypeCache represents cache-related errors
 	ErrorTypeCache ErrorType = "cache"
 	// ErrorTypePublisher represents publisher-related errors
@@ -56,6 +58,8 @@ func (e *CrawlerError) IsRetryable() bool {
 		return false
 	case ErrorTypeParsing:
 		return false
+	case ErrorTypeRefused:
+		return false
 	default:
 		return false
 	}
@@ -0,0 +1,70 @@
+// Package cachetest provides an in-memory cache for tests
+package cachetest
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCacheMiss is returned by Get for keys that are not in the cache
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache implements cache.CacheService in memory, remembering the expiration
+// each key was set with
+type Cache struct {
+	mu    sync.Mutex
+	items map[string][]byte
+	ttls  map[string]time.Duration
+}
+
+// New creates an empty cache
+func New() *Cache {
+	return &Cache{
+		items: make(map[string][]byte),
+		ttls:  make(map[string]time.Duration),
+	}
+}
+
+// Get retrieves a value from the cache
+func (c *Cache) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if val, ok := c.items[key]; ok {
+		return val, nil
+	}
+	return nil, ErrCacheMiss
+}
+
+// Set stores a value in the cache; it never expires
+func (c *Cache) Set(key string, value []byte, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = value
+	c.ttls[key] = expiration
+	return nil
+}
+
+// Delete removes a value from the cache
+func (c *Cache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	delete(c.ttls, key)
+	return nil
+}
+
+// Has reports whether the key is in the cache
+func (c *Cache) Has(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.items[key]
+	return ok
+}
+
+// TTL returns the expiration the key was last set with
+func (c *Cache) TTL(key string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttls[key]
+}
@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+)
+
+// maxKeyLength is the longest key memcache accepts
+const maxKeyLength = 250
+
+// Key returns the cache key for name under prefix. Names that would make an
+// invalid memcache key, such as long URLs or IDs holding spaces, are replaced
+// by their hash.
+func Key(prefix, name string) string {
+	key := prefix + name
+	if len(key) <= maxKeyLength && validKey(key) {
+		return key
+	}
+	sum := sha1.Sum([]byte(name))
+	return prefix + hex.EncodeToString(sum[:])
+}
+
+// validKey reports whether the key holds no spaces or control characters
+func validKey(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
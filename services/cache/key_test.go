@@ -0,0 +1,23 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "seen:Ppom:123", Key("seen:Ppom:", "123"))
+	assert.Equal(t, "redirect:https://coupa.ng/abc", Key("redirect:", "https://coupa.ng/abc"))
+
+	// Keys that memcache would reject are hashed
+	long := Key("image:", "https://example.com/"+strings.Repeat("a", 300))
+	assert.LessOrEqual(t, len(long), maxKeyLength)
+	assert.True(t, strings.HasPrefix(long, "image:"))
+
+	spaced := Key("seen:Ppom:", "a b")
+	assert.Len(t, spaced, len("seen:Ppom:")+40)
+	assert.NotEqual(t, spaced, Key("seen:Ppom:", "a c"))
+	assert.NotEqual(t, Key("seen:Ppom:", "a\x00"), "seen:Ppom:a\x00")
+}
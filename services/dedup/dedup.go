@@ -0,0 +1,127 @@
+package dedup
+
+import (
+	"encoding/json"
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+	"sjsage522/hotdealworker/services/cache"
+)
+
+const (
+	// keyPrefix is prepended to every seen-set key stored in the cache
+	keyPrefix = "seen:"
+
+	// boardKeyPrefix is prepended to the cache key holding a provider's board listing
+	boardKeyPrefix = "seen-board:"
+)
+
+// Snapshot holds the mutable fields of a deal as they were last published
+type Snapshot struct {
+	Fingerprint   string `json:"fingerprint"`
+	Id            string `json:"id,omitempty"`
+	Title         string `json:"title"`
+	Link          string `json:"link,omitempty"`
+	Price         string `json:"price,omitempty"`
+	ThumbnailLink string `json:"thumbnail_link,omitempty"`
+	Category      string `json:"category"`
+	Status        string `json:"status"`
+}
+
+// NewSnapshot captures the mutable fields of a deal.
+// Deals without a status are considered active.
+func NewSnapshot(deal crawler.HotDeal) Snapshot {
+	status := deal.Status
+	if status == "" {
+		status = crawler.StatusActive
+	}
+
+	return Snapshot{
+		Fingerprint:   deal.Fingerprint(),
+		Id:            deal.Id,
+		Title:         deal.Title,
+		Link:          deal.Link,
+		Price:         deal.Price,
+		ThumbnailLink: deal.ThumbnailLink,
+		Category:      deal.Category,
+		Status:        status,
+	}
+}
+
+// SeenSet remembers which deals have already been published, and what they
+// looked like, so that only new or edited deals reach the stream.
+// Entries expire after the TTL.
+type SeenSet struct {
+	cache cache.CacheService
+	ttl   time.Duration
+}
+
+// NewSeenSet creates a new seen-set backed by the given cache service
+func NewSeenSet(cacheSvc cache.CacheService, ttl time.Duration) *SeenSet {
+	return &SeenSet{
+		cache: cacheSvc,
+		ttl:   ttl,
+	}
+}
+
+// Lookup returns the snapshot recorded for the deal, or nil if it has not been seen.
+// Cache errors are treated as a miss so that a cache outage never drops deals.
+// An entry that cannot be decoded yields an empty snapshot without a fingerprint.
+func (s *SeenSet) Lookup(provider, id string) *Snapshot {
+	data, err := s.cache.Get(Key(provider, id))
+	if err != nil {
+		return nil
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return &Snapshot{}
+	}
+	if snapshot.Status == "" {
+		snapshot.Status = crawler.StatusActive
+	}
+	return &snapshot
+}
+
+// IsSeen reports whether the deal has already been recorded
+func (s *SeenSet) IsSeen(provider, id string) bool {
+	return s.Lookup(provider, id) != nil
+}
+
+// Record stores the snapshot of the deal for the configured TTL
+func (s *SeenSet) Record(provider, id string, snapshot Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(Key(provider, id), data, s.ttl)
+}
+
+// Board returns the keys of the deals listed on the provider's board in the
+// last cycle, in page order
+func (s *SeenSet) Board(provider string) []string {
+	data, err := s.cache.Get(boardKeyPrefix + provider)
+	if err != nil {
+		return nil
+	}
+
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil
+	}
+	return keys
+}
+
+// RecordBoard stores the keys of the deals currently listed on the provider's board
+func (s *SeenSet) RecordBoard(provider string, keys []string) error {
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(boardKeyPrefix+provider, data, s.ttl)
+}
+
+// Key returns the cache key for a provider's deal
+func Key(provider, id string) string {
+	return cache.Key(keyPrefix+provider+":", id)
+}
@@ -0,0 +1,58 @@
+package dedup
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+	"sjsage522/hotdealworker/services/cache/cachetest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeenSet(t *testing.T) {
+	mockCache := cachetest.New()
+	seen := NewSeenSet(mockCache, time.Hour)
+
+	assert.False(t, seen.IsSeen("Ppom", "123"))
+	assert.Nil(t, seen.Lookup("Ppom", "123"))
+
+	deal := crawler.HotDeal{Id: "123", Title: "Deal (10,000원)", Price: "10,000원", Category: "기타"}
+	err := seen.Record("Ppom", "123", NewSnapshot(deal))
+	assert.NoError(t, err)
+	assert.True(t, seen.IsSeen("Ppom", "123"))
+	assert.Equal(t, time.Hour, mockCache.TTL(Key("Ppom", "123")))
+
+	snapshot := seen.Lookup("Ppom", "123")
+	if assert.NotNil(t, snapshot) {
+		assert.Equal(t, deal.Fingerprint(), snapshot.Fingerprint)
+		assert.Equal(t, "10,000원", snapshot.Price)
+	}
+
+	// The same ID from another provider is a different deal
+	assert.False(t, seen.IsSeen("Quasar", "123"))
+
+	// Undecodable entries are still seen but carry no fingerprint
+	mockCache.Set(Key("Ppom", "legacy"), []byte("1"), time.Hour)
+	legacy := seen.Lookup("Ppom", "legacy")
+	if assert.NotNil(t, legacy) {
+		assert.Empty(t, legacy.Fingerprint)
+	}
+}
+
+func TestSnapshotFingerprint(t *testing.T) {
+	deal := crawler.HotDeal{Id: "1", Title: "Deal", Price: "1,000원", Category: "기타"}
+	edited := deal
+	edited.Price = "900원"
+	reposted := deal
+	reposted.PostedAt = "12:34"
+
+	assert.NotEqual(t, NewSnapshot(deal).Fingerprint, NewSnapshot(edited).Fingerprint)
+	assert.Equal(t, NewSnapshot(deal).Fingerprint, NewSnapshot(reposted).Fingerprint)
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "seen:Ppom:123", Key("Ppom", "123"))
+	assert.True(t, strings.HasPrefix(Key("Ppom", "a b"), "seen:Ppom:"))
+}
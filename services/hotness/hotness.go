@@ -0,0 +1,234 @@
+package hotness
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+	"sjsage522/hotdealworker/services/cache"
+)
+
+const (
+	// keyPrefix is prepended to every tracked deal key stored in the cache
+	keyPrefix = "hotness:"
+
+	// baselineKeyPrefix is prepended to the cache key holding a provider's baseline
+	baselineKeyPrefix = "hotness-baseline:"
+
+	// baselineWeight is how much a new cycle moves a provider's baseline
+	baselineWeight = 0.2
+
+	// minTrendingGain is the engagement a deal must gain before it can trend,
+	// so that a single vote on a quiet board is not announced
+	minTrendingGain = 5
+)
+
+// state is what the tracker remembers about a deal between cycles
+type state struct {
+	FirstSeenAt int64 `json:"first_seen_at"`
+	Engagement  int   `json:"engagement"`
+	Trending    bool  `json:"trending"`
+}
+
+// Score is the hotness of a deal in the current cycle
+type Score struct {
+	// Value is the deal's engagement velocity relative to its provider's baseline;
+	// 1 means as fast as a typical deal on that board
+	Value float64
+	// Trending is set when the deal crosses the threshold for the first time
+	Trending bool
+}
+
+// Tracker samples the vote and comment counts of deals every cycle and
+// scores them by how fast they gain engagement since they were first seen.
+// Velocities are normalized per provider because boards differ widely in traffic.
+type Tracker struct {
+	cache     cache.CacheService
+	ttl       time.Duration
+	threshold float64
+
+	mu        sync.Mutex
+	baselines map[string]float64
+}
+
+// NewTracker creates a new hotness tracker backed by the given cache service.
+// Deals scoring at least threshold are reported as trending.
+func NewTracker(cacheSvc cache.CacheService, ttl time.Duration, threshold float64) *Tracker {
+	return &Tracker{
+		cache:     cacheSvc,
+		ttl:       ttl,
+		threshold: threshold,
+		baselines: make(map[string]float64),
+	}
+}
+
+// Score returns the hotness of every deal that shows votes or comments, keyed by deal key.
+// A deal seen for the first time is scored at once when its posting time is known,
+// since it had no engagement when it was posted. Otherwise its counts are
+// remembered and it is left out until the next cycle gives it a velocity.
+func (t *Tracker) Score(provider string, deals []crawler.HotDeal, now time.Time) map[string]Score {
+	type sample struct {
+		key      string
+		velocity float64
+		gain     int
+		trending bool
+	}
+
+	var samples []sample
+	for _, deal := range deals {
+		engagement, ok := engagementOf(deal)
+		if !ok {
+			continue
+		}
+
+		key := deal.Key()
+		st := t.lookup(provider, key)
+		if st == nil {
+			postedAt, err := time.Parse(time.RFC3339, deal.PostedAt)
+			if err != nil || !postedAt.Before(now) {
+				t.record(provider, key, state{FirstSeenAt: now.Unix(), Engagement: engagement})
+				continue
+			}
+			st = &state{FirstSeenAt: postedAt.Unix()}
+			t.record(provider, key, *st)
+		}
+
+		// Count at least a minute so that a deal seen moments ago does not spike
+		minutes := now.Sub(time.Unix(st.FirstSeenAt, 0)).Minutes()
+		if minutes < 1 {
+			minutes = 1
+		}
+
+		gain := engagement - st.Engagement
+		if gain < 0 {
+			gain = 0
+		}
+		samples = append(samples, sample{
+			key:      key,
+			velocity: float64(gain) / minutes,
+			gain:     gain,
+			trending: st.Trending,
+		})
+	}
+
+	if len(samples) == 0 {
+		return nil
+	}
+
+	total := 0.0
+	for _, s := range samples {
+		total += s.velocity
+	}
+	baseline := t.updateBaseline(provider, total/float64(len(samples)))
+
+	scores := make(map[string]Score, len(samples))
+	for _, s := range samples {
+		var score Score
+		if baseline > 0 {
+			score.Value = s.velocity / baseline
+		}
+		score.Trending = !s.trending && s.gain >= minTrendingGain && score.Value >= t.threshold
+		scores[s.key] = score
+	}
+	return scores
+}
+
+// MarkTrending remembers that the deal's trending event has been published
+func (t *Tracker) MarkTrending(provider, key string) error {
+	st := t.lookup(provider, key)
+	if st == nil {
+		return nil
+	}
+	st.Trending = true
+	return t.record(provider, key, *st)
+}
+
+// Baseline returns the provider's typical engagement velocity per minute
+func (t *Tracker) Baseline(provider string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.baselineOf(provider)
+}
+
+// updateBaseline folds the cycle's mean velocity into the provider's baseline
+// and stores it in the cache so that it survives restarts
+func (t *Tracker) updateBaseline(provider string, mean float64) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	baseline := t.baselineOf(provider)
+	if baseline == 0 {
+		baseline = mean
+	} else {
+		baseline = baselineWeight*mean + (1-baselineWeight)*baseline
+	}
+	t.baselines[provider] = baseline
+	_ = t.cache.Set(cache.Key(baselineKeyPrefix, provider), []byte(strconv.FormatFloat(baseline, 'g', -1, 64)), t.ttl)
+	return baseline
+}
+
+// baselineOf returns the provider's baseline, loading it from the cache after
+// a restart; t.mu must be held. Cache errors are treated as no baseline.
+func (t *Tracker) baselineOf(provider string) float64 {
+	if baseline, ok := t.baselines[provider]; ok {
+		return baseline
+	}
+
+	data, err := t.cache.Get(cache.Key(baselineKeyPrefix, provider))
+	if err != nil {
+		return 0
+	}
+	baseline, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return 0
+	}
+	t.baselines[provider] = baseline
+	return baseline
+}
+
+// lookup returns the tracked state of a deal, or nil if it is not tracked.
+// Cache errors are treated as a miss.
+func (t *Tracker) lookup(provider, key string) *state {
+	data, err := t.cache.Get(cacheKey(provider, key))
+	if err != nil {
+		return nil
+	}
+
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil
+	}
+	return &st
+}
+
+// record stores the tracked state of a deal for the configured TTL
+func (t *Tracker) record(provider, key string, st state) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return t.cache.Set(cacheKey(provider, key), data, t.ttl)
+}
+
+// cacheKey returns the cache key for a provider's deal
+func cacheKey(provider, key string) string {
+	return cache.Key(keyPrefix+provider+":", key)
+}
+
+// engagementOf returns the deal's votes plus comments, and false if it shows neither
+func engagementOf(deal crawler.HotDeal) (int, bool) {
+	if deal.Votes == nil && deal.Comments == nil {
+		return 0, false
+	}
+
+	engagement := 0
+	if deal.Votes != nil {
+		engagement += *deal.Votes
+	}
+	if deal.Comments != nil {
+		engagement += *deal.Comments
+	}
+	return engagement, true
+}
@@ -0,0 +1,134 @@
+package hotness
+
+import (
+	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+	"sjsage522/hotdealworker/services/cache/cachetest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func deal(id string, votes, comments int) crawler.HotDeal {
+	return crawler.HotDeal{Id: id, Votes: intPtr(votes), Comments: intPtr(comments)}
+}
+
+func TestScore(t *testing.T) {
+	tracker := NewTracker(cachetest.New(), time.Hour, 2)
+	start := time.Date(2025, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	// Without posting times the first sighting only records the counts
+	scores := tracker.Score("Ppom", []crawler.HotDeal{
+		deal("1", 10, 2),
+		deal("2", 0, 0),
+		deal("3", 1, 0),
+		{Id: "4"},
+	}, start)
+	assert.Empty(t, scores)
+	assert.Equal(t, 0.0, tracker.Baseline("Ppom"))
+
+	// Ten minutes later deal 1 gained 60, deal 2 gained nothing and deal 3 gained 3
+	scores = tracker.Score("Ppom", []crawler.HotDeal{
+		deal("1", 50, 22),
+		deal("2", 0, 0),
+		deal("3", 4, 0),
+	}, start.Add(10*time.Minute))
+
+	// Velocities are 6, 0 and 0.3 per minute, so the baseline is 2.1
+	assert.InDelta(t, 2.1, tracker.Baseline("Ppom"), 0.001)
+	assert.InDelta(t, 6/2.1, scores["1"].Value, 0.001)
+	assert.True(t, scores["1"].Trending)
+	assert.Equal(t, 0.0, scores["2"].Value)
+	assert.False(t, scores["3"].Trending)
+
+	// Deals only trend once
+	assert.NoError(t, tracker.MarkTrending("Ppom", "1"))
+	scores = tracker.Score("Ppom", []crawler.HotDeal{
+		deal("1", 80, 30),
+		deal("2", 0, 0),
+		deal("3", 4, 0),
+	}, start.Add(20*time.Minute))
+	assert.Greater(t, scores["1"].Value, 2.0)
+	assert.False(t, scores["1"].Trending)
+}
+
+func TestScoreNormalizedPerProvider(t *testing.T) {
+	tracker := NewTracker(cachetest.New(), time.Hour, 2)
+	start := time.Date(2025, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tracker.Score("Ppom", []crawler.HotDeal{deal("1", 0, 0), deal("2", 0, 0)}, start)
+	tracker.Score("Arca", []crawler.HotDeal{deal("1", 0, 0), deal("2", 0, 0)}, start)
+
+	// The same gain is hot on a quiet board and ordinary on a busy one
+	ppom := tracker.Score("Ppom", []crawler.HotDeal{deal("1", 300, 0), deal("2", 300, 0)}, start.Add(10*time.Minute))
+	arca := tracker.Score("Arca", []crawler.HotDeal{deal("1", 10, 0), deal("2", 0, 0)}, start.Add(10*time.Minute))
+
+	assert.InDelta(t, 1.0, ppom["1"].Value, 0.001)
+	assert.False(t, ppom["1"].Trending)
+	assert.InDelta(t, 2.0, arca["1"].Value, 0.001)
+	assert.True(t, arca["1"].Trending)
+}
+
+func TestScoreRequiresMinimumGain(t *testing.T) {
+	tracker := NewTracker(cachetest.New(), time.Hour, 2)
+	start := time.Date(2025, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tracker.Score("Ppom", []crawler.HotDeal{deal("1", 0, 0), deal("2", 0, 0)}, start)
+	scores := tracker.Score("Ppom", []crawler.HotDeal{deal("1", 1, 0), deal("2", 0, 0)}, start.Add(10*time.Minute))
+
+	// A single vote on a quiet board scores high but does not trend
+	assert.InDelta(t, 2.0, scores["1"].Value, 0.001)
+	assert.False(t, scores["1"].Trending)
+}
+
+func TestScoreFromPostedAt(t *testing.T) {
+	tracker := NewTracker(cachetest.New(), time.Hour, 2)
+	start := time.Date(2025, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	posted := func(id string, votes int, ago time.Duration) crawler.HotDeal {
+		d := deal(id, votes, 0)
+		d.PostedAt = start.Add(-ago).Format(time.RFC3339)
+		return d
+	}
+
+	// Deals are scored on first sighting from the engagement gained since posting
+	scores := tracker.Score("Ppom", []crawler.HotDeal{
+		posted("1", 60, 10*time.Minute),
+		posted("2", 10, 10*time.Minute),
+		posted("3", 5, 5*time.Minute),
+		deal("4", 3, 0),
+	}, start)
+
+	// Velocities are 6, 1 and 1 per minute, so the baseline is 8/3
+	assert.Len(t, scores, 3)
+	assert.InDelta(t, 8.0/3, tracker.Baseline("Ppom"), 0.001)
+	assert.InDelta(t, 6/(8.0/3), scores["1"].Value, 0.001)
+	assert.True(t, scores["1"].Trending)
+	assert.NotContains(t, scores, "4")
+
+	// Later cycles keep measuring from the posting time
+	scores = tracker.Score("Ppom", []crawler.HotDeal{posted("2", 40, 20*time.Minute)}, start.Add(10*time.Minute))
+	assert.Contains(t, scores, "2")
+}
+
+func TestBaselineSurvivesRestart(t *testing.T) {
+	cacheSvc := cachetest.New()
+	start := time.Date(2025, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tracker := NewTracker(cacheSvc, time.Hour, 2)
+	tracker.Score("Ppom", []crawler.HotDeal{deal("1", 0, 0), deal("2", 0, 0)}, start)
+	tracker.Score("Ppom", []crawler.HotDeal{deal("1", 40, 0), deal("2", 0, 0)}, start.Add(10*time.Minute))
+	assert.InDelta(t, 2.0, tracker.Baseline("Ppom"), 0.001)
+
+	// A new tracker picks up the baseline and the deal states from the cache
+	restarted := NewTracker(cacheSvc, time.Hour, 2)
+	assert.InDelta(t, 2.0, restarted.Baseline("Ppom"), 0.001)
+	scores := restarted.Score("Ppom", []crawler.HotDeal{deal("1", 40, 0), deal("2", 0, 0)}, start.Add(20*time.Minute))
+	assert.InDelta(t, 0.2*1+0.8*2.0, restarted.Baseline("Ppom"), 0.001)
+	assert.InDelta(t, 2/1.8, scores["1"].Value, 0.001)
+}
@@ -0,0 +1,59 @@
+package imagestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Store keeps images under a key derived from their content, so the same
+// image is stored once however many deals or links point at it
+type Store interface {
+	// Put stores the image and returns its key
+	Put(ctx context.Context, data []byte) (string, error)
+
+	// URL returns where the image with the key can be fetched,
+	// or an empty string when the store has no public URL
+	URL(key string) string
+}
+
+// extensions maps the detected content types of images to file extensions
+var extensions = map[string]string{
+	"image/jpeg":    "jpg",
+	"image/png":     "png",
+	"image/gif":     "gif",
+	"image/webp":    "webp",
+	"image/bmp":     "bmp",
+	"image/x-icon":  "ico",
+	"image/svg+xml": "svg",
+}
+
+// Key returns the key of the image: the SHA-256 of its content with an
+// extension for its type, under a directory named by the first two hex
+// digits so no directory grows too large, e.g. "ab/ab12…ef.jpg"
+func Key(data []byte) string {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	ext, ok := extensions[ContentType(data)]
+	if !ok {
+		ext = "bin"
+	}
+	return hash[:2] + "/" + hash + "." + ext
+}
+
+// ContentType returns the MIME type of the image without parameters
+func ContentType(data []byte) string {
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	return contentType
+}
+
+// joinURL joins the base URL and the key, or returns an empty string without a base URL
+func joinURL(baseURL, key string) string {
+	if baseURL == "" {
+		return ""
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + key
+}
@@ -0,0 +1,62 @@
+package imagestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore keeps images in a directory on the local filesystem
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore creates a store writing images under dir, which is created
+// on the first write. Images are served from baseURL by whatever serves the
+// directory; keys have no URL without it.
+func NewLocalStore(dir, baseURL string) *LocalStore {
+	return &LocalStore{dir: dir, baseURL: baseURL}
+}
+
+// Put writes the image unless an image with the same content is already stored
+func (s *LocalStore) Put(_ context.Context, data []byte) (string, error) {
+	key := Key(data)
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if _, err := os.Stat(path); err == nil {
+		return key, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create image directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial image
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".image-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create image file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write image: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write image: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write image: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to store image: %w", err)
+	}
+
+	return key, nil
+}
+
+// URL returns the image's URL under the base URL
+func (s *LocalStore) URL(key string) string {
+	return joinURL(s.baseURL, key)
+}
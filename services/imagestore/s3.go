@@ -0,0 +1,152 @@
+package imagestore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"sjsage522/hotdealworker/helpers"
+)
+
+// S3Options configures an S3-compatible store such as AWS S3, MinIO or R2
+type S3Options struct {
+	// Endpoint is the service URL, e.g. https://s3.ap-northeast-2.amazonaws.com
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	// BaseURL is where stored images are served from (defaults to the bucket's URL)
+	BaseURL string
+	Timeout time.Duration
+}
+
+// S3Store keeps images in a bucket of an S3-compatible service.
+// Requests are signed with AWS Signature Version 4 and address the bucket by path.
+type S3Store struct {
+	opts   S3Options
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Store creates a store writing images to the bucket
+func NewS3Store(opts S3Options) *S3Store {
+	opts.Endpoint = strings.TrimRight(opts.Endpoint, "/")
+	if opts.BaseURL == "" {
+		opts.BaseURL = opts.Endpoint + "/" + opts.Bucket
+	}
+	return &S3Store{
+		opts:   opts,
+		client: helpers.NewClient(opts.Timeout),
+		now:    time.Now,
+	}
+}
+
+// Put uploads the image unless an object with the same key already exists
+func (s *S3Store) Put(ctx context.Context, data []byte) (string, error) {
+	key := Key(data)
+
+	resp, err := s.do(ctx, http.MethodHead, key, nil, "")
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return key, nil
+	}
+
+	resp, err = s.do(ctx, http.MethodPut, key, data, ContentType(data))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to upload image %s: status %d", key, resp.StatusCode)
+	}
+
+	return key, nil
+}
+
+// URL returns the image's URL under the base URL
+func (s *S3Store) URL(key string) string {
+	return joinURL(s.opts.BaseURL, key)
+}
+
+// do sends a signed request for the object with the key
+func (s *S3Store) do(ctx context.Context, method, key string, body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.opts.Endpoint+"/"+s.opts.Bucket+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach image store: %w", err)
+	}
+	return resp, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to the request
+func (s *S3Store) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := hashHex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.opts.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, s.opts.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+// hashHex returns the hex-encoded SHA-256 of the data
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data with the key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
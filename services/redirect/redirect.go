@@ -0,0 +1,179 @@
+package redirect
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"sjsage522/hotdealworker/helpers"
+	"sjsage522/hotdealworker/services/cache"
+)
+
+const (
+	// keyPrefix is prepended to every resolved link stored in the cache
+	keyPrefix = "redirect:"
+
+	// userAgent is sent with every request; some shorteners refuse clients without one
+	userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.0.0 Safari/537.36"
+)
+
+// Resolver follows the redirects of short links and redirectors, such as
+// link.coupang.com, coupa.ng or bit.ly, to the page they land on.
+// Resolved links are cached for the TTL so each one is followed only once.
+type Resolver struct {
+	cache   cache.CacheService
+	ttl     time.Duration
+	maxHops int
+	client  *http.Client
+
+	// timeout bounds each request; hostTimeouts override it for a host and its subdomains
+	timeout      time.Duration
+	hostTimeouts map[string]time.Duration
+}
+
+// NewResolver creates a resolver that follows at most maxHops redirects,
+// giving each request the timeout unless hostTimeouts has one for its host.
+// The cache may be nil.
+func NewResolver(cacheSvc cache.CacheService, ttl time.Duration, maxHops int, timeout time.Duration, hostTimeouts map[string]time.Duration) *Resolver {
+	timeouts := make(map[string]time.Duration, len(hostTimeouts))
+	for host, hostTimeout := range hostTimeouts {
+		timeouts[strings.ToLower(strings.TrimPrefix(host, "www."))] = hostTimeout
+	}
+
+	return &Resolver{
+		cache:   cacheSvc,
+		ttl:     ttl,
+		maxHops: maxHops,
+		client: &http.Client{
+			// Redirects are followed one hop at a time so that each hop gets its host's timeout
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+			// Each hop is paced like any other request to its host
+			Transport: helpers.Transport(),
+		},
+		timeout:      timeout,
+		hostTimeouts: timeouts,
+	}
+}
+
+// Resolve returns the URL the link finally lands on. Links that do not
+// redirect resolve to themselves. It gives up when the context is done.
+func (r *Resolver) Resolve(ctx context.Context, link string) (string, error) {
+	key := Key(link)
+	if r.cache != nil {
+		if data, err := r.cache.Get(key); err == nil && len(data) > 0 {
+			return string(data), nil
+		}
+	}
+
+	final, err := r.follow(ctx, link)
+	if err != nil {
+		return "", err
+	}
+
+	if r.cache != nil {
+		// A cache outage only costs another resolution later
+		_ = r.cache.Set(key, []byte(final), r.ttl)
+	}
+	return final, nil
+}
+
+// follow requests the link and each location it redirects to, up to the hop limit
+func (r *Resolver) follow(ctx context.Context, link string) (string, error) {
+	current, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return "", fmt.Errorf("invalid link %q: %w", link, err)
+	}
+
+	for hops := 0; ; hops++ {
+		if current.Scheme != "http" && current.Scheme != "https" {
+			return "", fmt.Errorf("unsupported link %q", current.String())
+		}
+
+		location, err := r.next(ctx, current)
+		if err != nil {
+			return "", err
+		}
+		if location == nil {
+			return current.String(), nil
+		}
+		if hops >= r.maxHops {
+			return "", fmt.Errorf("%s: more than %d redirects", link, r.maxHops)
+		}
+		current = location
+	}
+}
+
+// next returns where the URL redirects to, or nil if it does not redirect.
+// It asks with HEAD first and falls back to GET for servers that refuse HEAD.
+func (r *Resolver) next(ctx context.Context, u *url.URL) (*url.URL, error) {
+	resp, err := r.request(ctx, http.MethodHead, u)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		resp, err = r.request(ctx, http.MethodGet, u)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return nil, nil
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return nil, nil
+	}
+	next, err := u.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid redirect location %q: %w", u, location, err)
+	}
+	return next, nil
+}
+
+// request sends a request without reading the body and returns the response
+// with its status and headers only
+func (r *Resolver) request(ctx context.Context, method string, u *url.URL) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeoutFor(u.Hostname()))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", u, err)
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// timeoutFor returns the timeout for the host or its closest parent domain
+func (r *Resolver) timeoutFor(host string) time.Duration {
+	host = strings.ToLower(strings.TrimPrefix(host, "www."))
+	for host != "" {
+		if timeout, ok := r.hostTimeouts[host]; ok {
+			return timeout
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return r.timeout
+}
+
+// Key returns the cache key holding the resolved link
+func Key(link string) string {
+	return cache.Key(keyPrefix, link)
+}
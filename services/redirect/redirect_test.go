@@ -0,0 +1,118 @@
+package redirect
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/services/cache/cachetest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newChainServer serves /hop/N redirecting to /hop/N-1, down to /product which does not redirect
+func newChainServer(t *testing.T, requests *int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hop/", func(w http.ResponseWriter, req *http.Request) {
+		*requests++
+		n, err := strconv.Atoi(req.URL.Path[len("/hop/"):])
+		assert.NoError(t, err)
+		if n <= 1 {
+			http.Redirect(w, req, "/product?id=1", http.StatusFound)
+			return
+		}
+		http.Redirect(w, req, "/hop/"+strconv.Itoa(n-1), http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, req *http.Request) {
+		*requests++
+		if req.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		http.Redirect(w, req, "/product?id=2", http.StatusFound)
+	})
+	mux.HandleFunc("/product", func(w http.ResponseWriter, req *http.Request) {
+		*requests++
+		w.Write([]byte("product"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		http.Redirect(w, req, "/product", http.StatusFound)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResolve(t *testing.T) {
+	requests := 0
+	server := newChainServer(t, &requests)
+	mockCache := cachetest.New()
+	resolver := NewResolver(mockCache, time.Hour, 5, time.Second, nil)
+
+	final, err := resolver.Resolve(context.Background(), server.URL+"/hop/3")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/product?id=1", final)
+	assert.Equal(t, 4, requests)
+	assert.Equal(t, time.Hour, mockCache.TTL(Key(server.URL+"/hop/3")))
+
+	// The second resolution is served from the cache
+	final, err = resolver.Resolve(context.Background(), server.URL+"/hop/3")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/product?id=1", final)
+	assert.Equal(t, 4, requests)
+
+	// A link that does not redirect resolves to itself
+	final, err = resolver.Resolve(context.Background(), server.URL+"/product")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/product", final)
+
+	// Servers refusing HEAD are asked with GET
+	final, err = resolver.Resolve(context.Background(), server.URL+"/get-only")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/product?id=2", final)
+}
+
+func TestResolveHopLimit(t *testing.T) {
+	requests := 0
+	server := newChainServer(t, &requests)
+	mockCache := cachetest.New()
+	resolver := NewResolver(mockCache, time.Hour, 2, time.Second, nil)
+
+	final, err := resolver.Resolve(context.Background(), server.URL+"/hop/2")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/product?id=1", final)
+
+	_, err = resolver.Resolve(context.Background(), server.URL+"/hop/3")
+	assert.Error(t, err)
+	assert.False(t, mockCache.Has(Key(server.URL+"/hop/3")))
+}
+
+func TestResolveHostTimeout(t *testing.T) {
+	requests := 0
+	server := newChainServer(t, &requests)
+
+	// The host timeout overrides the default one
+	resolver := NewResolver(nil, time.Hour, 5, time.Second, map[string]time.Duration{"127.0.0.1": 50 * time.Millisecond})
+	_, err := resolver.Resolve(context.Background(), server.URL+"/slow")
+	assert.Error(t, err)
+
+	resolver = NewResolver(nil, time.Hour, 5, time.Second, nil)
+	final, err := resolver.Resolve(context.Background(), server.URL+"/slow")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/product", final)
+}
+
+func TestResolveInvalidLink(t *testing.T) {
+	resolver := NewResolver(nil, time.Hour, 5, time.Second, nil)
+
+	_, err := resolver.Resolve(context.Background(), "ftp://example.com/file")
+	assert.Error(t, err)
+
+	_, err = resolver.Resolve(context.Background(), "::not a link")
+	assert.Error(t, err)
+}
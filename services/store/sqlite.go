@@ -0,0 +1,144 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+
+	_ "modernc.org/sqlite"
+)
+
+// schema creates the deal history tables
+const schema = `
+CREATE TABLE IF NOT EXISTS deals (
+	provider      TEXT    NOT NULL,
+	deal_id       TEXT    NOT NULL,
+	title         TEXT    NOT NULL,
+	link          TEXT    NOT NULL,
+	category      TEXT    NOT NULL DEFAULT '',
+	price         TEXT    NOT NULL DEFAULT '',
+	status        TEXT    NOT NULL DEFAULT 'active',
+	first_seen_at INTEGER NOT NULL,
+	last_seen_at  INTEGER NOT NULL,
+	PRIMARY KEY (provider, deal_id)
+);
+CREATE INDEX IF NOT EXISTS idx_deals_first_seen_at ON deals (first_seen_at);
+CREATE INDEX IF NOT EXISTS idx_deals_last_seen_at ON deals (last_seen_at);
+`
+
+// upsertDeal inserts a deal or refreshes a known one, keeping its first-seen time
+const upsertDeal = `
+INSERT INTO deals (provider, deal_id, title, link, category, price, status, first_seen_at, last_seen_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (provider, deal_id) DO UPDATE SET
+	title        = excluded.title,
+	link         = excluded.link,
+	category     = excluded.category,
+	price        = excluded.price,
+	status       = excluded.status,
+	last_seen_at = excluded.last_seen_at
+`
+
+// SQLiteStore implements Store using an embedded SQLite database
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the SQLite database at the given path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// SaveDeals records the deals seen at the given time in a single transaction
+func (s *SQLiteStore) SaveDeals(deals []crawler.HotDeal, seenAt time.Time) error {
+	if len(deals) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(upsertDeal)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	ts := seenAt.Unix()
+	for _, deal := range deals {
+		status := deal.Status
+		if status == "" {
+			status = crawler.StatusActive
+		}
+
+		if _, err := stmt.Exec(
+			deal.Provider, deal.Key(), deal.Title, deal.Link,
+			deal.Category, deal.Price, status, ts, ts,
+		); err != nil {
+			return fmt.Errorf("failed to save deal %s: %w", deal.Key(), err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateStatus changes the lifecycle status of a known deal
+func (s *SQLiteStore) UpdateStatus(provider, dealID, status string) error {
+	_, err := s.db.Exec(
+		`UPDATE deals SET status = ? WHERE provider = ? AND deal_id = ?`,
+		status, provider, dealID,
+	)
+	return err
+}
+
+// GetDeal retrieves a deal record, or nil if the deal is unknown
+func (s *SQLiteStore) GetDeal(provider, dealID string) (*DealRecord, error) {
+	var (
+		record              DealRecord
+		firstSeen, lastSeen int64
+	)
+
+	err := s.db.QueryRow(`
+		SELECT provider, deal_id, title, link, category, price, status, first_seen_at, last_seen_at
+		FROM deals WHERE provider = ? AND deal_id = ?`,
+		provider, dealID,
+	).Scan(
+		&record.Provider, &record.DealID, &record.Title, &record.Link,
+		&record.Category, &record.Price, &record.Status, &firstSeen, &lastSeen,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record.FirstSeenAt = time.Unix(firstSeen, 0)
+	record.LastSeenAt = time.Unix(lastSeen, 0)
+	return &record, nil
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
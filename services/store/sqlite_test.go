@@ -0,0 +1,70 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer s.Close()
+
+	firstSeen := time.Unix(1700000000, 0)
+	deal := crawler.HotDeal{
+		Id:       "123",
+		Title:    "신라면 40봉 (19,900원)",
+		Link:     "https://www.ppomppu.co.kr/zboard/view.php?id=ppomppu&no=123",
+		Price:    "19,900원",
+		Category: "식품/먹거리",
+		Provider: crawler.ProviderPpom,
+	}
+
+	err = s.SaveDeals([]crawler.HotDeal{deal}, firstSeen)
+	assert.NoError(t, err)
+
+	record, err := s.GetDeal(crawler.ProviderPpom, "123")
+	assert.NoError(t, err)
+	if assert.NotNil(t, record) {
+		assert.Equal(t, "19,900원", record.Price)
+		assert.Equal(t, "식품/먹거리", record.Category)
+		assert.Equal(t, crawler.StatusActive, record.Status)
+		assert.Equal(t, firstSeen, record.FirstSeenAt)
+		assert.Equal(t, firstSeen, record.LastSeenAt)
+	}
+
+	// Seeing the deal again keeps the first-seen time
+	lastSeen := firstSeen.Add(time.Hour)
+	deal.Price = "17,900원"
+	err = s.SaveDeals([]crawler.HotDeal{deal}, lastSeen)
+	assert.NoError(t, err)
+
+	record, err = s.GetDeal(crawler.ProviderPpom, "123")
+	assert.NoError(t, err)
+	if assert.NotNil(t, record) {
+		assert.Equal(t, "17,900원", record.Price)
+		assert.Equal(t, firstSeen, record.FirstSeenAt)
+		assert.Equal(t, lastSeen, record.LastSeenAt)
+	}
+
+	// Status updates
+	err = s.UpdateStatus(crawler.ProviderPpom, "123", crawler.StatusDeleted)
+	assert.NoError(t, err)
+	record, err = s.GetDeal(crawler.ProviderPpom, "123")
+	assert.NoError(t, err)
+	if assert.NotNil(t, record) {
+		assert.Equal(t, crawler.StatusDeleted, record.Status)
+	}
+
+	// Unknown deals
+	record, err = s.GetDeal(crawler.ProviderPpom, "456")
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+}
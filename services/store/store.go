@@ -0,0 +1,36 @@
+package store
+
+import (
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+)
+
+// DealRecord represents a deal as kept in the history store
+type DealRecord struct {
+	Provider    string
+	DealID      string
+	Title       string
+	Link        string
+	Category    string
+	Price       string
+	Status      string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+// Store represents a persistent deal history store
+type Store interface {
+	// SaveDeals records the deals seen at the given time.
+	// New deals get their first-seen time set, known deals only have their fields and last-seen time updated.
+	SaveDeals(deals []crawler.HotDeal, seenAt time.Time) error
+
+	// UpdateStatus changes the lifecycle status of a known deal
+	UpdateStatus(provider, dealID, status string) error
+
+	// GetDeal retrieves a deal record, or nil if the deal is unknown
+	GetDeal(provider, dealID string) (*DealRecord, error)
+
+	// Close closes the store
+	Close() error
+}
@@ -0,0 +1,79 @@
+package thumbnail
+
+import "image"
+
+const (
+	// blankSamples is how many pixels along each side are sampled to spot blank images
+	blankSamples = 64
+	// blankTolerance is the largest difference in any channel between the
+	// sampled pixels of an image still taken for a single color
+	blankTolerance = 8
+)
+
+// isBlank reports whether the sampled pixels of the image are all about the same color
+func isBlank(img *image.RGBA) bool {
+	bounds := img.Bounds()
+	stepX := max(1, bounds.Dx()/blankSamples)
+	stepY := max(1, bounds.Dy()/blankSamples)
+
+	var low, high [3]uint8
+	first := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			offset := img.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				v := img.Pix[offset+c]
+				if first || v < low[c] {
+					low[c] = v
+				}
+				if first || v > high[c] {
+					high[c] = v
+				}
+			}
+			first = false
+		}
+	}
+
+	for c := 0; c < 3; c++ {
+		if high[c]-low[c] > blankTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// resize scales the image down to the given size, averaging the source
+// pixels covered by each destination pixel
+func resize(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max((x+1)*srcWidth/width, x0+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(src.Pix[offset+c])
+					}
+					offset += 4
+				}
+			}
+
+			count := (y1 - y0) * (x1 - x0)
+			offset := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return dst
+}
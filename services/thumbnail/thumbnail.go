@@ -0,0 +1,182 @@
+package thumbnail
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	// Decoders for the formats boards serve thumbnails in
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Reasons a thumbnail is rejected
+const (
+	ReasonUnsupported = "unsupported_type"
+	ReasonCorrupt     = "corrupt"
+	ReasonPlaceholder = "placeholder"
+	ReasonBlank       = "blank"
+	ReasonTooLarge    = "too_large"
+)
+
+const (
+	// maxPixels is the largest image decoded, so a tiny file declaring a huge
+	// canvas cannot exhaust memory; a decoded copy takes 4 bytes a pixel
+	maxPixels = 8_000_000
+	// minQuality is the lowest JPEG quality tried to fit the byte cap
+	minQuality = 40
+	// qualityStep is how much the quality drops on each try
+	qualityStep = 15
+)
+
+// RejectedError reports why a thumbnail was dropped
+type RejectedError struct {
+	Reason string
+	Detail string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("thumbnail rejected (%s): %s", e.Reason, e.Detail)
+}
+
+// reject returns a RejectedError with a formatted detail
+func reject(reason, format string, args ...interface{}) error {
+	return &RejectedError{Reason: reason, Detail: fmt.Sprintf(format, args...)}
+}
+
+// Options bound the thumbnails a Normalizer produces
+type Options struct {
+	// MaxWidth and MaxHeight are the box larger images are scaled down to fit
+	MaxWidth  int
+	MaxHeight int
+	// MinSize is the smallest width or height of a real thumbnail;
+	// anything smaller is taken for a tracking pixel or spacer
+	MinSize int
+	// Quality is the JPEG quality images are re-encoded at
+	Quality int
+	// MaxBytes is the largest thumbnail kept
+	MaxBytes int
+}
+
+// Normalizer turns downloaded images into small JPEG thumbnails
+type Normalizer struct {
+	opts Options
+}
+
+// NewNormalizer creates a normalizer with the given bounds
+func NewNormalizer(opts Options) *Normalizer {
+	return &Normalizer{opts: opts}
+}
+
+// Normalize validates the image and returns it scaled down to fit the
+// maximum dimensions and re-encoded as JPEG within the byte cap.
+// Transparent areas are flattened onto white. WebP images cannot be decoded,
+// so they are only checked for size and kept as they are.
+// Images that cannot be used are rejected with a *RejectedError.
+func (n *Normalizer) Normalize(data []byte) ([]byte, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	case "image/webp":
+		return n.checkWebP(data)
+	default:
+		return nil, reject(ReasonUnsupported, "%s", contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, reject(ReasonCorrupt, "%v", err)
+	}
+	if err := n.checkSize(cfg.Width, cfg.Height); err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, reject(ReasonCorrupt, "%v", err)
+	}
+
+	flat := flatten(src)
+	if isBlank(flat) {
+		return nil, reject(ReasonBlank, "%dx%d image of a single color", cfg.Width, cfg.Height)
+	}
+
+	width, height := fit(cfg.Width, cfg.Height, n.opts.MaxWidth, n.opts.MaxHeight)
+	if width != cfg.Width || height != cfg.Height {
+		flat = resize(flat, width, height)
+	}
+
+	return n.encode(flat)
+}
+
+// checkSize rejects placeholders and images too large to decode
+func (n *Normalizer) checkSize(width, height int) error {
+	if width < n.opts.MinSize || height < n.opts.MinSize {
+		return reject(ReasonPlaceholder, "%dx%d is smaller than %dpx", width, height, n.opts.MinSize)
+	}
+	if width*height > maxPixels {
+		return reject(ReasonTooLarge, "%dx%d has too many pixels to decode", width, height)
+	}
+	return nil
+}
+
+// checkWebP keeps a WebP image that is neither a placeholder nor over the byte cap
+func (n *Normalizer) checkWebP(data []byte) ([]byte, error) {
+	width, height, err := webpSize(data)
+	if err != nil {
+		return nil, reject(ReasonCorrupt, "%v", err)
+	}
+	if err := n.checkSize(width, height); err != nil {
+		return nil, err
+	}
+	if len(data) > n.opts.MaxBytes {
+		return nil, reject(ReasonTooLarge, "%d byte WebP exceeds %d bytes", len(data), n.opts.MaxBytes)
+	}
+	return data, nil
+}
+
+// encode encodes the image as JPEG, lowering the quality until it fits the byte cap
+func (n *Normalizer) encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	for quality := n.opts.Quality; ; quality -= qualityStep {
+		if quality < minQuality {
+			quality = minQuality
+		}
+
+		buf.Reset()
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("error encoding thumbnail: %w", err)
+		}
+		if buf.Len() <= n.opts.MaxBytes {
+			return buf.Bytes(), nil
+		}
+		if quality == minQuality {
+			return nil, reject(ReasonTooLarge, "%d bytes at quality %d exceeds %d bytes", buf.Len(), quality, n.opts.MaxBytes)
+		}
+	}
+}
+
+// flatten draws the image onto a white canvas, dropping its transparency
+func flatten(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Over)
+	return dst
+}
+
+// fit returns the largest size with the image's aspect ratio that fits the box
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+
+	scale := float64(maxWidth) / float64(width)
+	if s := float64(maxHeight) / float64(height); s < scale {
+		scale = s
+	}
+	return max(1, int(float64(width)*scale+0.5)), max(1, int(float64(height)*scale+0.5))
+}
@@ -0,0 +1,152 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testOptions = Options{MaxWidth: 480, MaxHeight: 480, MinSize: 16, Quality: 80, MaxBytes: 100 * 1024}
+
+// gradient returns an image whose colors change across it
+func gradient(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 255 / width), uint8(y * 255 / height), 128, 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// assertRejected checks that err rejects the image for the reason
+func assertRejected(t *testing.T, err error, reason string) {
+	var rejected *RejectedError
+	if assert.True(t, errors.As(err, &rejected), "%v", err) {
+		assert.Equal(t, reason, rejected.Reason)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	n := NewNormalizer(testOptions)
+
+	// Large images are scaled down to fit, keeping their aspect ratio
+	data, err := n.Normalize(encodePNG(t, gradient(1200, 800)))
+	assert.NoError(t, err)
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 480, cfg.Width)
+	assert.Equal(t, 320, cfg.Height)
+
+	// Small images are re-encoded at their size
+	var buf bytes.Buffer
+	assert.NoError(t, gif.Encode(&buf, gradient(100, 60), nil))
+	data, err = n.Normalize(buf.Bytes())
+	assert.NoError(t, err)
+	cfg, err = jpeg.DecodeConfig(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, 100, cfg.Width)
+	assert.Equal(t, 60, cfg.Height)
+}
+
+func TestNormalizeRejects(t *testing.T) {
+	n := NewNormalizer(testOptions)
+
+	_, err := n.Normalize([]byte("<html>not found</html>"))
+	assertRejected(t, err, ReasonUnsupported)
+
+	_, err = n.Normalize([]byte("\x89PNG\r\n\x1a\ntruncated"))
+	assertRejected(t, err, ReasonCorrupt)
+
+	// Tracking pixels and spacers
+	_, err = n.Normalize(encodePNG(t, gradient(1, 1)))
+	assertRejected(t, err, ReasonPlaceholder)
+	_, err = n.Normalize(encodePNG(t, gradient(300, 8)))
+	assertRejected(t, err, ReasonPlaceholder)
+
+	// A single color, including a fully transparent image
+	blank := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	_, err = n.Normalize(encodePNG(t, blank))
+	assertRejected(t, err, ReasonBlank)
+	for i := range blank.Pix {
+		blank.Pix[i] = 0xf0
+	}
+	_, err = n.Normalize(encodePNG(t, blank))
+	assertRejected(t, err, ReasonBlank)
+
+	// Noise that stays over the byte cap at the lowest quality
+	noise := image.NewRGBA(image.Rect(0, 0, 480, 480))
+	random := rand.New(rand.NewSource(1))
+	random.Read(noise.Pix)
+	small := NewNormalizer(Options{MaxWidth: 480, MaxHeight: 480, MinSize: 16, Quality: 80, MaxBytes: 10 * 1024})
+	_, err = small.Normalize(encodePNG(t, noise))
+	assertRejected(t, err, ReasonTooLarge)
+}
+
+// webpLossless returns the header of a lossless WebP image of the given size
+func webpLossless(width, height int) []byte {
+	data := make([]byte, 30)
+	copy(data, "RIFF")
+	copy(data[8:], "WEBPVP8L")
+	data[20] = 0x2f
+	binary.LittleEndian.PutUint32(data[21:], uint32(width-1)|uint32(height-1)<<14)
+	return data
+}
+
+func TestNormalizeWebP(t *testing.T) {
+	n := NewNormalizer(testOptions)
+
+	data := webpLossless(300, 200)
+	kept, err := n.Normalize(data)
+	assert.NoError(t, err)
+	assert.Equal(t, data, kept)
+
+	_, err = n.Normalize(webpLossless(1, 1))
+	assertRejected(t, err, ReasonPlaceholder)
+
+	// A tiny file declaring a huge canvas is rejected before decoding
+	_, err = n.Normalize(webpLossless(2800, 2800))
+	assert.NoError(t, err)
+	_, err = n.Normalize(webpLossless(4000, 3000))
+	assertRejected(t, err, ReasonTooLarge)
+
+	width, height, err := webpSize(webpLossless(1024, 768))
+	assert.NoError(t, err)
+	assert.Equal(t, 1024, width)
+	assert.Equal(t, 768, height)
+}
+
+func TestFit(t *testing.T) {
+	testCases := []struct {
+		width, height int
+		fitWidth      int
+		fitHeight     int
+	}{
+		{400, 300, 400, 300},
+		{960, 480, 480, 240},
+		{480, 1440, 160, 480},
+		{5000, 2, 480, 1},
+	}
+
+	for _, tc := range testCases {
+		width, height := fit(tc.width, tc.height, 480, 480)
+		assert.Equal(t, tc.fitWidth, width, "%dx%d", tc.width, tc.height)
+		assert.Equal(t, tc.fitHeight, height, "%dx%d", tc.width, tc.height)
+	}
+}
@@ -0,0 +1,39 @@
+package thumbnail
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// webpSize reads the canvas size from the header of a WebP image
+func webpSize(data []byte) (int, int, error) {
+	if len(data) < 30 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0, errors.New("truncated WebP header")
+	}
+
+	// The first chunk follows the RIFF header; its payload starts at byte 20
+	switch string(data[12:16]) {
+	case "VP8 ":
+		// Lossy: a frame tag and start code, then 14-bit dimensions
+		if data[23] != 0x9d || data[24] != 0x01 || data[25] != 0x2a {
+			return 0, 0, errors.New("missing VP8 start code")
+		}
+		width := int(binary.LittleEndian.Uint16(data[26:28]) & 0x3fff)
+		height := int(binary.LittleEndian.Uint16(data[28:30]) & 0x3fff)
+		return width, height, nil
+	case "VP8L":
+		// Lossless: a signature byte, then 14-bit dimensions minus one
+		if data[20] != 0x2f {
+			return 0, 0, errors.New("missing VP8L signature")
+		}
+		bits := binary.LittleEndian.Uint32(data[21:25])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, nil
+	case "VP8X":
+		// Extended: flags, then 24-bit canvas dimensions minus one
+		width := int(data[24]) | int(data[25])<<8 | int(data[26])<<16
+		height := int(data[27]) | int(data[28])<<8 | int(data[29])<<16
+		return width + 1, height + 1, nil
+	default:
+		return 0, 0, errors.New("unknown WebP chunk " + string(data[12:16]))
+	}
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+)
+
+const (
+	// speedUpFactor shortens the interval after a run that found new deals
+	speedUpFactor = 0.5
+	// backOffFactor lengthens the interval after a run that found none
+	backOffFactor = 1.5
+)
+
+// adaptInterval returns the interval until the next run of a provider whose
+// schedule adapts. Runs that find new deals shorten the interval and idle
+// runs, such as those overnight, lengthen it, within the schedule's bounds.
+// A failed run says nothing about the board, so it keeps the interval.
+func adaptInterval(current time.Duration, schedule crawler.Schedule, base time.Duration, result crawlerResult) time.Duration {
+	minInterval, maxInterval := base, base
+	if schedule.MinInterval > 0 {
+		minInterval = schedule.MinInterval
+	}
+	if schedule.MaxInterval > 0 {
+		maxInterval = schedule.MaxInterval
+	}
+	if maxInterval < minInterval {
+		maxInterval = minInterval
+	}
+
+	if current <= 0 {
+		current = base
+	}
+	if result.Success {
+		factor := backOffFactor
+		if result.NewCount > 0 {
+			factor = speedUpFactor
+		}
+		current = time.Duration(float64(current) * factor)
+	}
+
+	return min(max(current, minInterval), maxInterval)
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"sjsage522/hotdealworker/internal/crawler"
+	"sjsage522/hotdealworker/services/dedup"
+)
+
+// EventType describes why a deal was published
+type EventType string
+
+const (
+	// EventCreated is published the first time a deal is seen
+	EventCreated EventType = "created"
+	// EventUpdated is published when a seen deal's title, price, thumbnail or category changes
+	EventUpdated EventType = "updated"
+	// EventEnded is published when a seen deal is marked as ended
+	EventEnded EventType = "ended"
+	// EventSoldOut is published when a seen deal is marked as sold out
+	EventSoldOut EventType = "sold_out"
+	// EventDeleted is published when a seen deal is removed from the board
+	EventDeleted EventType = "deleted"
+	// EventTrending is published when a deal's hotness crosses the trending threshold
+	EventTrending EventType = "trending"
+)
+
+// DealEvent is the message published to the stream for each deal.
+// The deal fields are inlined so that consumers reading a plain HotDeal keep working.
+type DealEvent struct {
+	crawler.HotDeal
+	Event    EventType       `json:"event"`
+	Previous *dedup.Snapshot `json:"previous,omitempty"`
+	Hotness  *float64        `json:"hotness,omitempty"`
+}
+
+// lifecycleEvent returns the event announcing a move to the given status,
+// or an empty event type if the status has no lifecycle event
+func lifecycleEvent(status string) EventType {
+	switch status {
+	case crawler.StatusEnded:
+		return EventEnded
+	case crawler.StatusSoldOut:
+		return EventSoldOut
+	case crawler.StatusDeleted:
+		return EventDeleted
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"sjsage522/hotdealworker/helpers"
+	"sjsage522/hotdealworker/internal/crawler"
+	"sjsage522/hotdealworker/logger"
+	"sjsage522/hotdealworker/pkg/errors"
+)
+
+// fetchWithRetry fetches the crawler's deals, retrying errors classified as
+// retryable with exponential backoff as the crawler's retry policy allows.
+// Failures are returned as a *errors.CrawlerError. Retries stop when the
+// context is done, so they never outlast the crawl's deadline.
+func (w *Worker) fetchWithRetry(ctx context.Context, log *logger.Logger, c crawler.Crawler, provider string) ([]crawler.HotDeal, int, error) {
+	policy := retryPolicyOf(c)
+
+	for attempt := 1; ; attempt++ {
+		deals, err := w.fetchDeals(ctx, c, provider)
+		if err == nil || stderrors.Is(err, crawler.ErrUnchanged) {
+			return deals, attempt, err
+		}
+
+		crawlerErr := classifyError(provider, err)
+		if !crawlerErr.IsRetryable() || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return nil, attempt, crawlerErr
+		}
+
+		wait := policy.Backoff(attempt)
+		if policy.Jitter > 0 {
+			wait += randomJitter(policy.Jitter)
+		}
+		log.Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Dur("backoff", wait).
+			Msg("Retrying fetch")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempt, crawlerErr
+		case <-timer.C:
+		}
+	}
+}
+
+// classifyError wraps a fetch error in a CrawlerError whose type tells
+// whether it is worth retrying. Rate limits and refused requests are not,
+// nor are pages every ChromeDB and FlareSolverr strategy failed on, since
+// the site is blocked for a while; failures to reach the site or server
+// errors are.
+func classifyError(provider string, err error) *errors.CrawlerError {
+	var crawlerErr *errors.CrawlerError
+	if stderrors.As(err, &crawlerErr) {
+		return crawlerErr
+	}
+
+	if stderrors.Is(err, crawler.ErrCoolingDown) {
+		return errors.New(errors.ErrorTypeRateLimit, provider, "Cooling down", err)
+	}
+	if stderrors.Is(err, crawler.ErrStrategiesFailed) {
+		return errors.New(errors.ErrorTypeRefused, provider, "All fetch strategies failed", err)
+	}
+
+	var statusErr *helpers.StatusError
+	if stderrors.As(err, &statusErr) {
+		switch {
+		case statusErr.RateLimited():
+			return errors.New(errors.ErrorTypeRateLimit, provider, "Rate limited", err)
+		case statusErr.StatusCode < 500 && statusErr.StatusCode != http.StatusRequestTimeout:
+			return errors.New(errors.ErrorTypeRefused, provider, "Request refused", err)
+		}
+	}
+
+	return errors.New(errors.ErrorTypeNetwork, provider, "Failed to fetch deals", err)
+}
+
+// retryPolicyOf returns the crawler's own retry policy, or one that never retries
+func retryPolicyOf(c crawler.Crawler) crawler.RetryPolicy {
+	if retrying, ok := c.(crawler.RetryingCrawler); ok {
+		return retrying.CrawlRetry()
+	}
+	return crawler.RetryPolicy{}
+}
@@ -0,0 +1,137 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/helpers"
+	"sjsage522/hotdealworker/internal/crawler"
+	"sjsage522/hotdealworker/services/cache/cachetest"
+	apperrors "sjsage522/hotdealworker/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockFlakyCrawler fails with the given errors before returning its deals
+type mockFlakyCrawler struct {
+	mockCrawler
+	failures []error
+	policy   crawler.RetryPolicy
+	fetched  int
+}
+
+func (m *mockFlakyCrawler) FetchDeals(ctx context.Context) ([]crawler.HotDeal, error) {
+	m.fetched++
+	if m.fetched <= len(m.failures) {
+		return nil, m.failures[m.fetched-1]
+	}
+	return m.mockCrawler.FetchDeals(ctx)
+}
+
+func (m *mockFlakyCrawler) CrawlRetry() crawler.RetryPolicy {
+	return m.policy
+}
+
+func TestCrawlAndPublishRetries(t *testing.T) {
+	policy := crawler.RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	deals := []crawler.HotDeal{{Id: "1", Title: "Deal 1", Link: "https://example.com/1"}}
+	blip := fmt.Errorf("failed to fetch URL: %w", errors.New("connection reset by peer"))
+
+	testCases := []struct {
+		name     string
+		failures []error
+		success  bool
+		fetched  int
+		errType  apperrors.ErrorType
+	}{
+		{"network blips", []error{blip, &helpers.StatusError{StatusCode: http.StatusBadGateway}}, true, 3, ""},
+		{"too many failures", []error{blip, blip, blip}, false, 3, apperrors.ErrorTypeNetwork},
+		{"rate limited", []error{&helpers.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute}}, false, 1, apperrors.ErrorTypeRateLimit},
+		{"cooling down", []error{fmt.Errorf("blocked: %w", crawler.ErrCoolingDown)}, false, 1, apperrors.ErrorTypeRateLimit},
+		{"refused", []error{&helpers.StatusError{StatusCode: http.StatusForbidden}}, false, 1, apperrors.ErrorTypeRefused},
+		{"all strategies failed", []error{fmt.Errorf("%w for URL: https://example.com", crawler.ErrStrategiesFailed)}, false, 1, apperrors.ErrorTypeRefused},
+		{"parsing", []error{apperrors.NewParsing("Mock", "HTML 파싱 오류", errors.New("bad markup"))}, false, 1, apperrors.ErrorTypeParsing},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &mockFlakyCrawler{mockCrawler: mockCrawler{deals: deals}, failures: tc.failures, policy: policy}
+			pub := &mockPublisher{}
+			w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, nil, nil, nil, time.Minute)
+
+			result := w.crawlAndPublish(c)
+			assert.Equal(t, tc.success, result.Success)
+			assert.Equal(t, tc.fetched, c.fetched)
+			if tc.success {
+				assert.Len(t, pub.messages, 1)
+				return
+			}
+
+			var crawlerErr *apperrors.CrawlerError
+			if assert.ErrorAs(t, result.Error, &crawlerErr) {
+				assert.Equal(t, tc.errType, crawlerErr.Type)
+			}
+			assert.Empty(t, pub.messages)
+		})
+	}
+}
+
+func TestCrawlAndPublishRetryStopsAtDeadline(t *testing.T) {
+	blip := errors.New("connection reset by peer")
+	c := &mockFlakyCrawler{
+		failures: []error{blip, blip, blip},
+		policy:   crawler.RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Minute},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := NewWorker(ctx, []crawler.Crawler{c}, &mockPublisher{}, nil, nil, nil, time.Minute)
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	// The backoff is abandoned with the worker's context
+	start := time.Now()
+	result := w.crawlAndPublish(c)
+	assert.False(t, result.Success)
+	assert.Equal(t, 1, c.fetched)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestCrawlAndPublishChromeCrawlerCoolingDown(t *testing.T) {
+	requests := 0
+	chromeDB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer chromeDB.Close()
+
+	// The site was blocked when every strategy failed on an earlier run
+	cacheSvc := cachetest.New()
+	assert.NoError(t, cacheSvc.Set("chrome_rate_limited", []byte("60"), time.Minute))
+
+	c := crawler.NewUnifiedCrawler(crawler.CrawlerConfig{
+		URL:          "https://example.com",
+		Provider:     "Chrome",
+		CacheKey:     "chrome_rate_limited",
+		BlockTime:    60,
+		UseChrome:    true,
+		ChromeDBAddr: chromeDB.URL,
+		Retry:        crawler.RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond},
+		Selectors:    crawler.Selectors{DealList: "div.deal", Title: "a", Link: "a"},
+	}, cacheSvc)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, &mockPublisher{}, nil, nil, nil, time.Minute)
+
+	// Neither ChromeDB nor FlareSolverr is asked until the block expires,
+	// and the cooldown is not retried
+	result := w.crawlAndPublish(c)
+	assert.False(t, result.Success)
+	assert.ErrorIs(t, result.Error, crawler.ErrCoolingDown)
+	var crawlerErr *apperrors.CrawlerError
+	if assert.ErrorAs(t, result.Error, &crawlerErr) {
+		assert.Equal(t, apperrors.ErrorTypeRateLimit, crawlerErr.Type)
+	}
+	assert.Equal(t, 0, requests)
+}
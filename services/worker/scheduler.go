@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+)
+
+// scheduleTick is how often the worker looks for crawlers that are due
+const scheduleTick = time.Second
+
+// providerRun is the schedule state of one provider
+type providerRun struct {
+	next     time.Time
+	running  bool
+	interval time.Duration
+}
+
+// scheduler decides when each provider runs. Providers are tracked by name so
+// that their schedule survives a reload replacing the crawler. A provider is
+// never started while its previous run is still going.
+type scheduler struct {
+	mu              sync.Mutex
+	defaultInterval time.Duration
+	runs            map[string]*providerRun
+	// jitter returns a random delay of at most max
+	jitter func(max time.Duration) time.Duration
+}
+
+// newScheduler creates a scheduler that runs crawlers without their own
+// interval every defaultInterval
+func newScheduler(defaultInterval time.Duration) *scheduler {
+	return &scheduler{
+		defaultInterval: defaultInterval,
+		runs:            make(map[string]*providerRun),
+		jitter:          randomJitter,
+	}
+}
+
+// due returns the crawlers whose next run has come and marks them as running.
+// A provider seen for the first time is due at once, or at the end of its
+// quiet hours.
+func (s *scheduler) due(crawlers []crawler.Crawler, now time.Time) []crawler.Crawler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []crawler.Crawler
+	current := make(map[string]bool, len(crawlers))
+	for _, c := range crawlers {
+		provider := c.GetProvider()
+		current[provider] = true
+
+		run, ok := s.runs[provider]
+		if !ok {
+			run = &providerRun{next: scheduleOf(c).Resume(now)}
+			s.runs[provider] = run
+		}
+		if run.running || now.Before(run.next) {
+			continue
+		}
+
+		run.running = true
+		due = append(due, c)
+	}
+
+	// Forget providers that were removed once their last run is over
+	for provider, run := range s.runs {
+		if !current[provider] && !run.running {
+			delete(s.runs, provider)
+		}
+	}
+
+	return due
+}
+
+// finish marks the crawler's run as over and schedules its next run one
+// interval, plus jitter, after the run finished and outside its quiet hours.
+// Adaptive schedules adjust the interval to the run's result first.
+// It returns the time of the next run and the interval used.
+func (s *scheduler) finish(c crawler.Crawler, finished time.Time, result crawlerResult) (time.Time, time.Duration) {
+	schedule := scheduleOf(c)
+
+	base := schedule.Interval
+	if base <= 0 {
+		base = s.defaultInterval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	provider := c.GetProvider()
+	run, ok := s.runs[provider]
+	if !ok {
+		run = &providerRun{}
+		s.runs[provider] = run
+	}
+
+	if schedule.Adaptive() {
+		run.interval = adaptInterval(run.interval, schedule, base, result)
+	} else {
+		run.interval = base
+	}
+
+	next := finished.Add(run.interval)
+	if schedule.Jitter > 0 {
+		next = next.Add(s.jitter(schedule.Jitter))
+	}
+	run.next = schedule.Resume(next)
+	run.running = false
+
+	return run.next, run.interval
+}
+
+// intervals returns the current interval of every provider that has run
+func (s *scheduler) intervals() map[string]time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	intervals := make(map[string]time.Duration, len(s.runs))
+	for provider, run := range s.runs {
+		if run.interval > 0 {
+			intervals[provider] = run.interval
+		}
+	}
+	return intervals
+}
+
+// scheduleOf returns the crawler's own schedule, or an empty one
+func scheduleOf(c crawler.Crawler) crawler.Schedule {
+	if scheduled, ok := c.(crawler.ScheduledCrawler); ok {
+		return scheduled.CrawlSchedule()
+	}
+	return crawler.Schedule{}
+}
+
+// randomJitter returns a random delay between zero and max
+func randomJitter(max time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(max) + 1))
+}
@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockScheduledCrawler runs on its own schedule
+type mockScheduledCrawler struct {
+	mockCrawler
+	provider string
+	schedule crawler.Schedule
+}
+
+func (m *mockScheduledCrawler) GetProvider() string {
+	return m.provider
+}
+
+func (m *mockScheduledCrawler) CrawlSchedule() crawler.Schedule {
+	return m.schedule
+}
+
+// nextRun finishes a successful run that found no new deals and returns the next run
+func nextRun(s *scheduler, c crawler.Crawler, finished time.Time) time.Time {
+	next, _ := s.finish(c, finished, crawlerResult{Success: true})
+	return next
+}
+
+func TestSchedulerDue(t *testing.T) {
+	fast := &mockScheduledCrawler{provider: "Fast", schedule: crawler.Schedule{Interval: 30 * time.Second}}
+	plain := &mockCrawler{}
+	crawlers := []crawler.Crawler{fast, plain}
+
+	s := newScheduler(time.Minute)
+	now := time.Now()
+
+	// New providers are due at once
+	assert.Len(t, s.due(crawlers, now), 2)
+
+	// A provider is not started again while it is running
+	assert.Empty(t, s.due(crawlers, now.Add(time.Hour)))
+
+	assert.Equal(t, now.Add(30*time.Second), nextRun(s, fast, now))
+	assert.Equal(t, now.Add(time.Minute), nextRun(s, plain, now))
+
+	assert.Empty(t, s.due(crawlers, now.Add(29*time.Second)))
+	assert.Equal(t, []crawler.Crawler{fast}, s.due(crawlers, now.Add(30*time.Second)))
+	assert.Equal(t, []crawler.Crawler{plain}, s.due(crawlers, now.Add(time.Minute)))
+
+	// Removed providers are forgotten once their run is over
+	nextRun(s, plain, now)
+	s.due([]crawler.Crawler{fast}, now)
+	assert.NotContains(t, s.runs, "Mock")
+	assert.Contains(t, s.runs, "Fast")
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	c := &mockScheduledCrawler{provider: "Jittery", schedule: crawler.Schedule{Interval: time.Minute, Jitter: 10 * time.Second}}
+	s := newScheduler(time.Minute)
+	now := time.Now()
+
+	for i := 0; i < 100; i++ {
+		next := nextRun(s, c, now)
+		assert.False(t, next.Before(now.Add(time.Minute)))
+		assert.False(t, next.After(now.Add(time.Minute+10*time.Second)))
+	}
+
+	s.jitter = func(max time.Duration) time.Duration { return max }
+	assert.Equal(t, now.Add(70*time.Second), nextRun(s, c, now))
+}
+
+func TestSchedulerQuietHours(t *testing.T) {
+	quiet := crawler.QuietWindow{Start: 1 * 60, End: 7 * 60}
+	c := &mockScheduledCrawler{provider: "Quiet", schedule: crawler.Schedule{Interval: time.Hour, QuietHours: []crawler.QuietWindow{quiet}}}
+	s := newScheduler(time.Minute)
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 1, hour, minute, 0, 0, crawler.KST)
+	}
+
+	// A provider first seen during its quiet hours waits for them to end
+	assert.Empty(t, s.due([]crawler.Crawler{c}, at(2, 0)))
+	assert.Len(t, s.due([]crawler.Crawler{c}, at(7, 0)), 1)
+
+	// A run that would fall in the quiet hours is pushed to their end
+	assert.Equal(t, at(7, 0), nextRun(s, c, at(0, 30)))
+	assert.Equal(t, at(9, 0), nextRun(s, c, at(8, 0)))
+}
+
+func TestSchedulerAdaptiveInterval(t *testing.T) {
+	c := &mockScheduledCrawler{provider: "Adaptive", schedule: crawler.Schedule{
+		Interval:    time.Minute,
+		MinInterval: 20 * time.Second,
+		MaxInterval: 5 * time.Minute,
+	}}
+	s := newScheduler(time.Minute)
+	now := time.Now()
+
+	busy := crawlerResult{Success: true, NewCount: 3}
+	idle := crawlerResult{Success: true}
+
+	// New deals speed the provider up down to its minimum
+	_, interval := s.finish(c, now, busy)
+	assert.Equal(t, 30*time.Second, interval)
+	_, interval = s.finish(c, now, busy)
+	assert.Equal(t, 20*time.Second, interval)
+
+	// Idle runs back off up to the maximum
+	for i := 0; i < 10; i++ {
+		_, interval = s.finish(c, now, idle)
+	}
+	assert.Equal(t, 5*time.Minute, interval)
+
+	// Failed runs keep the interval
+	next, interval := s.finish(c, now, crawlerResult{})
+	assert.Equal(t, 5*time.Minute, interval)
+	assert.Equal(t, now.Add(5*time.Minute), next)
+	assert.Equal(t, map[string]time.Duration{"Adaptive": 5 * time.Minute}, s.intervals())
+
+	// Fixed schedules ignore the results
+	fixed := &mockScheduledCrawler{provider: "Fixed", schedule: crawler.Schedule{Interval: time.Minute}}
+	_, interval = s.finish(fixed, now, busy)
+	assert.Equal(t, time.Minute, interval)
+}
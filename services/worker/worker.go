@@ -3,147 +3,226 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"sjsage522/hotdealworker/internal/crawler"
 	"sjsage522/hotdealworker/logger"
-	"sjsage522/hotdealworker/pkg/errors"
+	"sjsage522/hotdealworker/services/dedup"
+	"sjsage522/hotdealworker/services/hotness"
 	"sjsage522/hotdealworker/services/publisher"
+	"sjsage522/hotdealworker/services/store"
+	"sjsage522/hotdealworker/services/thumbnail"
 )
 
 // Worker handles the crawling and publishing process
 type Worker struct {
 	ctx           context.Context
+	mu            sync.RWMutex
 	crawlers      []crawler.Crawler
 	publisher     publisher.Publisher
+	seen          *dedup.SeenSet
+	history       store.Store
+	hotness       *hotness.Tracker
 	crawlInterval time.Duration
 	logger        *logger.Logger
+
+	schedule *scheduler
+	runs     sync.WaitGroup
+
+	resultsMu sync.Mutex
+	results   CrawlResults
 }
 
-// NewWorker creates a new worker
+// NewWorker creates a new worker.
+// Crawlers without their own schedule run every crawlInterval.
+// If seen is nil, every fetched deal is published as created on every cycle.
+// If history is nil, deals are not persisted.
+// If tracker is nil, deals are not scored and no trending events are published.
 func NewWorker(
 	ctx context.Context,
 	crawlers []crawler.Crawler,
 	pub publisher.Publisher,
+	seen *dedup.SeenSet,
+	history store.Store,
+	tracker *hotness.Tracker,
 	crawlInterval time.Duration,
 ) *Worker {
 	return &Worker{
 		ctx:           ctx,
 		crawlers:      crawlers,
 		publisher:     pub,
+		seen:          seen,
+		history:       history,
+		hotness:       tracker,
 		crawlInterval: crawlInterval,
 		logger:        logger.ForWorker(),
+		schedule:      newScheduler(crawlInterval),
 	}
 }
 
-// Start starts the worker process
+// Start starts the worker process. Each crawler runs on its own schedule;
+// a summary of the runs is logged and the streams are trimmed every crawl interval.
 func (w *Worker) Start() error {
 	w.logger.Info().
-		Int("crawler_count", len(w.crawlers)).
+		Int("crawler_count", len(w.currentCrawlers())).
 		Dur("interval", w.crawlInterval).
 		Msg("Worker started")
 
-	ticker := time.NewTicker(w.crawlInterval)
-	defer ticker.Stop()
+	tick := time.NewTicker(scheduleTick)
+	defer tick.Stop()
+	summary := time.NewTicker(w.crawlInterval)
+	defer summary.Stop()
 
 	// Run immediately on start
-	w.runCrawlCycle()
+	w.startDueCrawlers(time.Now())
 
 	for {
 		select {
 		case <-w.ctx.Done():
 			w.logger.Info().Msg("Worker shutting down")
+			w.runs.Wait()
 			return nil
-		case <-ticker.C:
-			w.runCrawlCycle()
+		case now := <-tick.C:
+			w.startDueCrawlers(now)
+		case <-summary.C:
+			w.summarize()
 		}
 	}
 }
 
-// runCrawlCycle runs a single crawl cycle
-func (w *Worker) runCrawlCycle() {
-	start := time.Now()
+// SetCrawlers replaces the crawlers run by the worker.
+// Runs in progress finish with the crawlers they started with, and providers
+// that are still configured keep their schedule.
+func (w *Worker) SetCrawlers(crawlers []crawler.Crawler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.crawlers = crawlers
 
-	w.logger.Debug().Msg("Starting crawl cycle")
+	w.logger.Info().
+		Int("crawler_count", len(crawlers)).
+		Msg("Crawlers replaced")
+}
 
-	results := w.runCrawlers()
+// currentCrawlers returns the crawlers to schedule
+func (w *Worker) currentCrawlers() []crawler.Crawler {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.crawlers
+}
 
-	elapsed := time.Since(start)
+// startDueCrawlers starts every crawler whose next run has come.
+// A crawler whose previous run is still going is not started again.
+func (w *Worker) startDueCrawlers(now time.Time) {
+	for _, c := range w.schedule.due(w.currentCrawlers(), now) {
+		w.runs.Add(1)
+		go w.runCrawler(c)
+	}
+}
 
-	// Log cycle summary
-	w.logger.Info().
-		Dur("duration", elapsed).
-		Int("total_deals", results.TotalDeals).
-		Int("successful_crawlers", results.SuccessfulCrawlers).
-		Int("failed_crawlers", results.FailedCrawlers).
-		Msg("Crawl cycle completed")
+// runCrawler runs the crawler once and schedules its next run
+func (w *Worker) runCrawler(c crawler.Crawler) {
+	defer w.runs.Done()
+
+	result := w.crawlAndPublish(c)
+	w.addResult(result)
+
+	next, interval := w.schedule.finish(c, time.Now(), result)
+	w.logger.Debug().
+		Str("crawler", c.GetName()).
+		Bool("success", result.Success).
+		Int("new_deals", result.NewCount).
+		Dur("interval", interval).
+		Time("next_run", next).
+		Msg("Crawler run finished")
+}
+
+// Intervals returns the current crawl interval of every provider that has run
+func (w *Worker) Intervals() map[string]time.Duration {
+	return w.schedule.intervals()
 }
 
-// CrawlResults holds the results of a crawl cycle
+// CrawlResults holds the results of the crawler runs since the last summary
 type CrawlResults struct {
 	TotalDeals         int
+	FetchedDeals       int
+	NewDeals           int
+	UpdatedDeals       int
+	LifecycleEvents    int
+	TrendingEvents     int
 	SuccessfulCrawlers int
 	FailedCrawlers     int
 }
 
-// runCrawlers runs all the crawlers in parallel and then trims the streams
-func (w *Worker) runCrawlers() CrawlResults {
-	var (
-		wg      sync.WaitGroup
-		mu      sync.Mutex
-		results CrawlResults
-	)
-
-	// Create a channel for crawler results
-	resultChan := make(chan crawlerResult, len(w.crawlers))
-
-	// Run crawlers in parallel
-	for _, c := range w.crawlers {
-		wg.Add(1)
-		go func(crawler crawler.Crawler) {
-			defer wg.Done()
-
-			result := w.crawlAndPublish(crawler)
-			resultChan <- result
-		}(c)
+// addResult adds a crawler run to the results of the current summary period
+func (w *Worker) addResult(result crawlerResult) {
+	w.resultsMu.Lock()
+	defer w.resultsMu.Unlock()
+
+	if result.Success {
+		w.results.SuccessfulCrawlers++
+		w.results.TotalDeals += result.DealCount
+		w.results.FetchedDeals += result.FetchedCount
+		w.results.NewDeals += result.NewCount
+		w.results.UpdatedDeals += result.UpdatedCount
+		w.results.LifecycleEvents += result.LifecycleCount
+		w.results.TrendingEvents += result.TrendingCount
+	} else {
+		w.results.FailedCrawlers++
 	}
+}
 
-	// Wait for all crawlers to complete
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+// takeResults returns the results of the current summary period and starts a new one
+func (w *Worker) takeResults() CrawlResults {
+	w.resultsMu.Lock()
+	defer w.resultsMu.Unlock()
 
-	// Collect results
-	for result := range resultChan {
-		mu.Lock()
-		if result.Success {
-			results.SuccessfulCrawlers++
-			results.TotalDeals += result.DealCount
-		} else {
-			results.FailedCrawlers++
-		}
-		mu.Unlock()
-	}
+	results := w.results
+	w.results = CrawlResults{}
+	return results
+}
 
-	// Trim all streams after crawling
+// summarize trims the streams and logs the runs since the last summary
+func (w *Worker) summarize() {
 	if err := w.publisher.TrimStreams(); err != nil {
 		w.logger.Error().
 			Err(err).
 			Msg("Failed to trim streams")
 	}
 
-	return results
+	intervals := make(map[string]string)
+	for provider, interval := range w.Intervals() {
+		intervals[provider] = interval.String()
+	}
+
+	results := w.takeResults()
+	w.logger.Info().
+		Dur("period", w.crawlInterval).
+		Int("total_deals", results.TotalDeals).
+		Int("fetched_deals", results.FetchedDeals).
+		Int("new_deals", results.NewDeals).
+		Int("updated_deals", results.UpdatedDeals).
+		Int("lifecycle_events", results.LifecycleEvents).
+		Int("trending_events", results.TrendingEvents).
+		Int("successful_crawlers", results.SuccessfulCrawlers).
+		Int("failed_crawlers", results.FailedCrawlers).
+		Interface("intervals", intervals).
+		Msg("Crawl summary")
 }
 
 // crawlerResult holds the result of a single crawler run
 type crawlerResult struct {
-	CrawlerName string
-	Success     bool
-	DealCount   int
-	Error       error
+	CrawlerName    string
+	Success        bool
+	DealCount      int
+	FetchedCount   int
+	NewCount       int
+	UpdatedCount   int
+	LifecycleCount int
+	TrendingCount  int
+	Error          error
 }
 
 // crawlAndPublish crawls deals from a crawler and publishes them
@@ -162,30 +241,71 @@ func (w *Worker) crawlAndPublish(c crawler.Crawler) crawlerResult {
 	default:
 	}
 
-	// Fetch deals
+	// The crawler's deadline covers fetching the deals and their detail pages;
+	// publishing is bound by the worker's context only
+	crawlCtx := w.ctx
+	if timed, ok := c.(crawler.TimeoutCrawler); ok && timed.CrawlTimeout() > 0 {
+		var cancel context.CancelFunc
+		crawlCtx, cancel = context.WithTimeout(w.ctx, timed.CrawlTimeout())
+		defer cancel()
+	}
+
+	// Fetch deals, retrying transient failures
 	log.Debug().Msg("Fetching deals")
-	deals, err := c.FetchDeals()
+	deals, attempts, err := w.fetchWithRetry(crawlCtx, log, c, provider)
+	if stderrors.Is(err, crawler.ErrUnchanged) {
+		// Nothing changed on the board, so there is nothing to parse or publish
+		log.Debug().Msg("Board unchanged")
+		commitFetch(c)
+		result.Success = true
+		return result
+	}
 	if err != nil {
-		// Check if it's a custom error
-		var crawlerErr *errors.CrawlerError
-		if cErr, ok := err.(*errors.CrawlerError); ok {
-			crawlerErr = cErr
-		} else {
-			crawlerErr = errors.New(errors.ErrorTypeNetwork, provider, "Failed to fetch deals", err)
-		}
-
+		crawlerErr := classifyError(provider, err)
 		log.Error().
 			Err(crawlerErr).
+			Str("error_type", string(crawlerErr.Type)).
 			Bool("retryable", crawlerErr.IsRetryable()).
+			Int("attempts", attempts).
 			Msg("Failed to fetch deals")
 
 		result.Error = crawlerErr
 		return result
 	}
 
-	// Publish deals
+	result.FetchedCount = len(deals)
+
+	// Record every fetched deal in the history store
+	if w.history != nil {
+		if err := w.history.SaveDeals(deals, time.Now()); err != nil {
+			log.Warn().
+				Err(err).
+				Msg("Failed to save deal history")
+		}
+	}
+
+	// Work out which deals need an event, including deals removed from the board
+	pending := w.collectEvents(log, provider, deals)
+
+	// Score engagement velocity and announce deals that start trending
+	if w.hotness != nil {
+		pending = w.applyHotness(provider, deals, pending)
+	}
+
+	// Read the detail pages of new deals before announcing them
+	if detailer, ok := c.(crawler.DetailCrawler); ok && detailer.HasDetail() {
+		w.enrichDeals(crawlCtx, log, detailer, pending)
+	}
+
+	// Download thumbnails only for the deals about to be announced
+	if loader, ok := c.(crawler.ThumbnailCrawler); ok {
+		w.loadThumbnails(crawlCtx, log, loader, pending)
+	}
+
+	// Publish events
 	publishedCount := 0
-	for _, deal := range deals {
+	failed := false
+	for _, p := range pending {
 		// Check context for each deal
 		select {
 		case <-w.ctx.Done():
@@ -196,37 +316,359 @@ func (w *Worker) crawlAndPublish(c crawler.Crawler) crawlerResult {
 		default:
 		}
 
-		dealData, err := json.Marshal(deal)
+		switch p.event.Event {
+		case EventCreated:
+			result.NewCount++
+		case EventUpdated:
+			result.UpdatedCount++
+		case EventTrending:
+			result.TrendingCount++
+		default:
+			result.LifecycleCount++
+		}
+
+		dealData, err := json.Marshal(p.event)
 		if err != nil {
 			log.Error().
 				Err(err).
-				Str("deal_id", deal.Id).
+				Str("deal_id", p.event.Id).
 				Msg("Failed to marshal deal")
+			failed = true
 			continue
 		}
 
 		if err := w.publisher.Publish(provider, dealData); err != nil {
 			log.Error().
 				Err(err).
-				Str("deal_id", deal.Id).
+				Str("deal_id", p.event.Id).
 				Msg("Failed to publish deal")
+			failed = true
 			continue
 		}
 
+		if p.event.Event == EventTrending {
+			if err := w.hotness.MarkTrending(provider, p.key); err != nil {
+				log.Warn().
+					Err(err).
+					Str("deal_id", p.event.Id).
+					Msg("Failed to mark deal as trending")
+			}
+		} else if w.seen != nil {
+			w.recordSeen(log, provider, p.key, p.snapshot)
+		}
+
+		// Deleted deals are no longer fetched, so their status is recorded separately
+		if w.history != nil && p.event.Event == EventDeleted {
+			if err := w.history.UpdateStatus(provider, p.key, crawler.StatusDeleted); err != nil {
+				log.Warn().
+					Err(err).
+					Str("deal_id", p.event.Id).
+					Msg("Failed to update deal history status")
+			}
+		}
+
 		publishedCount++
 	}
 
+	// Remember the board listing for the next cycle's deletion check
+	if w.seen != nil && len(deals) > 0 {
+		keys := make([]string, len(deals))
+		for i, deal := range deals {
+			keys[i] = deal.Key()
+		}
+		if err := w.seen.RecordBoard(provider, keys); err != nil {
+			log.Warn().
+				Err(err).
+				Msg("Failed to record board listing")
+		}
+	}
+
+	// Only a run that published everything in time lets the next one skip an
+	// unchanged board; otherwise the board is parsed again and the deals retried
+	if !failed && crawlCtx.Err() == nil {
+		commitFetch(c)
+	}
+
 	// Log summary
 	if publishedCount > 0 {
 		log.Info().
 			Int("fetched", len(deals)).
+			Int("new", result.NewCount).
+			Int("updated", result.UpdatedCount).
+			Int("lifecycle", result.LifecycleCount).
+			Int("trending", result.TrendingCount).
 			Int("published", publishedCount).
 			Msg("Deals processed")
 	} else if len(deals) == 0 {
 		log.Debug().Msg("No deals found")
+	} else {
+		log.Debug().
+			Int("fetched", len(deals)).
+			Msg("No new or updated deals")
 	}
 
 	result.Success = true
 	result.DealCount = publishedCount
 	return result
 }
+
+// commitFetch lets the crawler skip its source until it changes again
+func commitFetch(c crawler.Crawler) {
+	if committer, ok := c.(crawler.CommittingCrawler); ok {
+		committer.CommitFetch()
+	}
+}
+
+// fetchDeals fetches the crawler's deals. Crawlers that can page back keep
+// paging until they reach a deal listed in the previous cycle, so deals that
+// scrolled off the first page during downtime or a busy spell are not lost.
+// Without a previous listing there is nothing to catch up to.
+func (w *Worker) fetchDeals(ctx context.Context, c crawler.Crawler, provider string) ([]crawler.HotDeal, error) {
+	pager, ok := c.(crawler.CatchUpCrawler)
+	if !ok || w.seen == nil {
+		return c.FetchDeals(ctx)
+	}
+
+	previous := w.seen.Board(provider)
+	if len(previous) == 0 {
+		return c.FetchDeals(ctx)
+	}
+
+	listed := make(map[string]bool, len(previous))
+	for _, key := range previous {
+		listed[key] = true
+	}
+	return pager.FetchDealsUntil(ctx, func(key string) bool {
+		return listed[key]
+	})
+}
+
+// pendingEvent is an event waiting to be published along with the
+// snapshot to record once it has been published
+type pendingEvent struct {
+	key      string
+	event    DealEvent
+	snapshot dedup.Snapshot
+}
+
+// collectEvents compares the fetched deals against the seen-set and returns
+// the events to publish, in page order, followed by deletions
+func (w *Worker) collectEvents(log *logger.Logger, provider string, deals []crawler.HotDeal) []pendingEvent {
+	var pending []pendingEvent
+
+	for _, deal := range deals {
+		dealKey := deal.Key()
+		snapshot := dedup.NewSnapshot(deal)
+		event := DealEvent{HotDeal: deal, Event: EventCreated}
+
+		if w.seen == nil {
+			pending = append(pending, pendingEvent{key: dealKey, event: event, snapshot: snapshot})
+			continue
+		}
+
+		previous := w.seen.Lookup(provider, dealKey)
+		if previous == nil {
+			// Deals that are already over when first seen are not worth announcing
+			if snapshot.Status != crawler.StatusActive {
+				continue
+			}
+			pending = append(pending, pendingEvent{key: dealKey, event: event, snapshot: snapshot})
+			continue
+		}
+
+		if previous.Fingerprint == "" {
+			// Entry without a fingerprint; adopt the current state without publishing
+			w.recordSeen(log, provider, dealKey, snapshot)
+			continue
+		}
+
+		statusChanged := previous.Status != snapshot.Status
+		if !statusChanged && previous.Fingerprint == snapshot.Fingerprint {
+			continue
+		}
+
+		event.Event = EventUpdated
+		event.Previous = previous
+		if statusChanged {
+			if lifecycle := lifecycleEvent(snapshot.Status); lifecycle != "" {
+				event.Event = lifecycle
+			}
+		}
+		pending = append(pending, pendingEvent{key: dealKey, event: event, snapshot: snapshot})
+	}
+
+	if w.seen == nil || len(deals) == 0 {
+		return pending
+	}
+
+	// Deals that vanished from the board while older deals are still listed were deleted
+	current := make([]string, len(deals))
+	for i, deal := range deals {
+		current[i] = deal.Key()
+	}
+	for _, dealKey := range findDeleted(w.seen.Board(provider), current) {
+		previous := w.seen.Lookup(provider, dealKey)
+		if previous == nil || previous.Fingerprint == "" || previous.Status == crawler.StatusDeleted {
+			continue
+		}
+
+		snapshot := *previous
+		snapshot.Status = crawler.StatusDeleted
+		event := DealEvent{
+			HotDeal: crawler.HotDeal{
+				Id:            previous.Id,
+				Title:         previous.Title,
+				Link:          previous.Link,
+				Price:         previous.Price,
+				ThumbnailLink: previous.ThumbnailLink,
+				Category:      previous.Category,
+				Provider:      provider,
+				Status:        crawler.StatusDeleted,
+			},
+			Event:    EventDeleted,
+			Previous: previous,
+		}
+		pending = append(pending, pendingEvent{key: dealKey, event: event, snapshot: snapshot})
+	}
+
+	return pending
+}
+
+// applyHotness attaches the hotness score to the pending events of fetched deals
+// and appends a trending event for every deal that crossed the threshold
+func (w *Worker) applyHotness(provider string, deals []crawler.HotDeal, pending []pendingEvent) []pendingEvent {
+	scores := w.hotness.Score(provider, deals, time.Now())
+	if len(scores) == 0 {
+		return pending
+	}
+
+	for i := range pending {
+		if pending[i].event.Event == EventDeleted {
+			continue
+		}
+		if score, ok := scores[pending[i].key]; ok {
+			value := score.Value
+			pending[i].event.Hotness = &value
+		}
+	}
+
+	for _, deal := range deals {
+		dealKey := deal.Key()
+		score, ok := scores[dealKey]
+		if !ok || !score.Trending {
+			continue
+		}
+		// Deals that are already over are not worth announcing
+		if deal.Status != "" && deal.Status != crawler.StatusActive {
+			continue
+		}
+
+		value := score.Value
+		pending = append(pending, pendingEvent{
+			key:      dealKey,
+			event:    DealEvent{HotDeal: deal, Event: EventTrending, Hotness: &value},
+			snapshot: dedup.NewSnapshot(deal),
+		})
+	}
+
+	return pending
+}
+
+// enrichDeals fills in the created events' deals from their detail pages in
+// parallel; the crawler's limiter caps how many pages are fetched at once.
+// Deals whose detail page fails or runs past the deadline are published as
+// listed. Only the event is enriched, so the recorded snapshot still matches
+// the next cycle's listing.
+func (w *Worker) enrichDeals(ctx context.Context, log *logger.Logger, c crawler.DetailCrawler, pending []pendingEvent) {
+	var wg sync.WaitGroup
+	for i := range pending {
+		if pending[i].event.Event != EventCreated {
+			continue
+		}
+
+		wg.Add(1)
+		go func(deal *crawler.HotDeal) {
+			defer wg.Done()
+			if err := c.EnrichDeal(ctx, deal); err != nil {
+				log.Warn().
+					Err(err).
+					Str("deal_id", deal.Id).
+					Msg("Failed to read deal detail page")
+			}
+		}(&pending[i].event.HotDeal)
+	}
+	wg.Wait()
+}
+
+// loadThumbnails downloads the thumbnails of the pending events' deals in
+// parallel; the crawler's limiter caps how many are downloaded at once.
+// Deleted deals are not announced with a thumbnail, and deals whose thumbnail
+// fails are published without one. A rejected thumbnail also drops its link.
+func (w *Worker) loadThumbnails(ctx context.Context, log *logger.Logger, c crawler.ThumbnailCrawler, pending []pendingEvent) {
+	var wg sync.WaitGroup
+	for i := range pending {
+		deal := &pending[i].event.HotDeal
+		if pending[i].event.Event == EventDeleted || deal.ThumbnailLink == "" || deal.Thumbnail != "" || deal.ThumbnailKey != "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(deal *crawler.HotDeal) {
+			defer wg.Done()
+			err := c.LoadThumbnail(ctx, deal)
+			var rejected *thumbnail.RejectedError
+			if stderrors.As(err, &rejected) {
+				// Consumers are not pointed at an image that will never load;
+				// the recorded snapshot keeps the link, so it is not an update
+				deal.ThumbnailLink = ""
+				log.Info().
+					Str("deal_id", deal.Id).
+					Str("reason", rejected.Reason).
+					Str("detail", rejected.Detail).
+					Msg("Thumbnail dropped")
+			} else if err != nil {
+				log.Warn().
+					Err(err).
+					Str("deal_id", deal.Id).
+					Msg("Failed to load thumbnail")
+			}
+		}(deal)
+	}
+	wg.Wait()
+}
+
+// findDeleted returns the keys from the previous board listing that are
+// missing from the current one while a deal listed below them is still present.
+// Deals missing at the bottom of the listing may simply have scrolled off the page.
+func findDeleted(previous, current []string) []string {
+	present := make(map[string]bool, len(current))
+	for _, key := range current {
+		present[key] = true
+	}
+
+	// Only deals above the lowest deal still listed can be judged
+	lowest := -1
+	for i, key := range previous {
+		if present[key] {
+			lowest = i
+		}
+	}
+
+	var deleted []string
+	for i := 0; i < lowest; i++ {
+		if !present[previous[i]] {
+			deleted = append(deleted, previous[i])
+		}
+	}
+	return deleted
+}
+
+// recordSeen stores the deal's snapshot in the seen-set
+func (w *Worker) recordSeen(log *logger.Logger, provider, dealKey string, snapshot dedup.Snapshot) {
+	if err := w.seen.Record(provider, dealKey, snapshot); err != nil {
+		log.Warn().
+			Err(err).
+			Str("deal_key", dealKey).
+			Msg("Failed to record deal as seen")
+	}
+}
@@ -0,0 +1,589 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"sjsage522/hotdealworker/internal/crawler"
+	"sjsage522/hotdealworker/services/cache/cachetest"
+	"sjsage522/hotdealworker/services/dedup"
+	"sjsage522/hotdealworker/services/hotness"
+	"sjsage522/hotdealworker/services/thumbnail"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockCrawler returns a fixed set of deals
+type mockCrawler struct {
+	deals []crawler.HotDeal
+}
+
+func (m *mockCrawler) FetchDeals(context.Context) ([]crawler.HotDeal, error) {
+	return m.deals, nil
+}
+
+func (m *mockCrawler) GetName() string {
+	return "MockCrawler"
+}
+
+func (m *mockCrawler) GetProvider() string {
+	return "Mock"
+}
+
+// mockPagedCrawler serves a board of several pages and can page back
+type mockPagedCrawler struct {
+	mockCrawler
+	pages   [][]crawler.HotDeal
+	fetched int
+}
+
+func (m *mockPagedCrawler) FetchDeals(context.Context) ([]crawler.HotDeal, error) {
+	m.fetched++
+	return m.pages[0], nil
+}
+
+func (m *mockPagedCrawler) FetchDealsUntil(_ context.Context, seen func(key string) bool) ([]crawler.HotDeal, error) {
+	var deals []crawler.HotDeal
+	for _, page := range m.pages {
+		m.fetched++
+		deals = append(deals, page...)
+		for _, deal := range page {
+			if seen(deal.Key()) {
+				return deals, nil
+			}
+		}
+	}
+	return deals, nil
+}
+
+// mockDetailCrawler fills in a shop URL from the detail page of every deal it is asked about
+type mockDetailCrawler struct {
+	mockCrawler
+	mu       sync.Mutex
+	enriched []string
+}
+
+func (m *mockDetailCrawler) HasDetail() bool {
+	return true
+}
+
+func (m *mockDetailCrawler) EnrichDeal(_ context.Context, deal *crawler.HotDeal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enriched = append(m.enriched, deal.Id)
+	if deal.Id == "2" {
+		return errors.New("detail page unavailable")
+	}
+	deal.ShopURL = "https://shop.example.com/" + deal.Id
+	return nil
+}
+
+// mockSlowCrawler never answers before its deadline
+type mockSlowCrawler struct {
+	mockCrawler
+	timeout time.Duration
+}
+
+func (m *mockSlowCrawler) FetchDeals(ctx context.Context) ([]crawler.HotDeal, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (m *mockSlowCrawler) CrawlTimeout() time.Duration {
+	return m.timeout
+}
+
+// mockThumbnailCrawler records the deals whose thumbnail it is asked to load
+type mockThumbnailCrawler struct {
+	mockCrawler
+	mu       sync.Mutex
+	loaded   []string
+	rejected map[string]bool
+}
+
+func (m *mockThumbnailCrawler) LoadThumbnail(_ context.Context, deal *crawler.HotDeal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loaded = append(m.loaded, deal.Id)
+	if m.rejected[deal.Id] {
+		return &thumbnail.RejectedError{Reason: thumbnail.ReasonPlaceholder, Detail: "1x1 is smaller than 16px"}
+	}
+	deal.Thumbnail = "encoded-" + deal.Id
+	return nil
+}
+
+// mockUnchangedCrawler reports its board as unchanged once it has been fetched
+type mockUnchangedCrawler struct {
+	mockCrawler
+	fetched bool
+}
+
+func (m *mockUnchangedCrawler) FetchDeals(context.Context) ([]crawler.HotDeal, error) {
+	if m.fetched {
+		return nil, crawler.ErrUnchanged
+	}
+	m.fetched = true
+	return m.deals, nil
+}
+
+// mockPublisher records every published message; the first failures publishes fail
+type mockPublisher struct {
+	mu       sync.Mutex
+	messages [][]byte
+	failures int
+}
+
+func (m *mockPublisher) Publish(key string, message []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("redis unavailable")
+	}
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func (m *mockPublisher) TrimStreams() error {
+	return nil
+}
+
+func (m *mockPublisher) Close() error {
+	return nil
+}
+
+func TestCrawlAndPublishSkipsSeenDeals(t *testing.T) {
+	c := &mockCrawler{deals: []crawler.HotDeal{
+		{Id: "1", Title: "Deal 1", Link: "https://example.com/1"},
+		{Id: "2", Title: "Deal 2", Link: "https://example.com/2"},
+	}}
+	pub := &mockPublisher{}
+	seen := dedup.NewSeenSet(cachetest.New(), time.Hour)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, seen, nil, nil, time.Minute)
+
+	// First cycle publishes everything
+	result := w.crawlAndPublish(c)
+	assert.True(t, result.Success)
+	assert.Equal(t, 2, result.FetchedCount)
+	assert.Equal(t, 2, result.NewCount)
+	assert.Equal(t, 2, result.DealCount)
+	assert.Len(t, pub.messages, 2)
+
+	// Second cycle only publishes the deal that was not seen before
+	c.deals = append(c.deals, crawler.HotDeal{Id: "3", Title: "Deal 3", Link: "https://example.com/3"})
+	result = w.crawlAndPublish(c)
+	assert.True(t, result.Success)
+	assert.Equal(t, 3, result.FetchedCount)
+	assert.Equal(t, 1, result.NewCount)
+	assert.Len(t, pub.messages, 3)
+
+	var event DealEvent
+	assert.NoError(t, json.Unmarshal(pub.messages[2], &event))
+	assert.Equal(t, "3", event.Id)
+	assert.Equal(t, EventCreated, event.Event)
+	assert.Nil(t, event.Previous)
+}
+
+func TestCrawlAndPublishUpdatedDeals(t *testing.T) {
+	c := &mockCrawler{deals: []crawler.HotDeal{
+		{Id: "1", Title: "Deal 1 (10,000원)", Price: "10,000원", Link: "https://example.com/1"},
+	}}
+	pub := &mockPublisher{}
+	seen := dedup.NewSeenSet(cachetest.New(), time.Hour)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, seen, nil, nil, time.Minute)
+
+	w.crawlAndPublish(c)
+
+	// The poster edits the price
+	c.deals[0].Title = "Deal 1 (9,000원)"
+	c.deals[0].Price = "9,000원"
+	result := w.crawlAndPublish(c)
+	assert.Equal(t, 0, result.NewCount)
+	assert.Equal(t, 1, result.UpdatedCount)
+	assert.Len(t, pub.messages, 2)
+
+	var event DealEvent
+	assert.NoError(t, json.Unmarshal(pub.messages[1], &event))
+	assert.Equal(t, EventUpdated, event.Event)
+	assert.Equal(t, "9,000원", event.Price)
+	if assert.NotNil(t, event.Previous) {
+		assert.Equal(t, "Deal 1 (10,000원)", event.Previous.Title)
+		assert.Equal(t, "10,000원", event.Previous.Price)
+	}
+
+	// Nothing is published when the deal is unchanged
+	w.crawlAndPublish(c)
+	assert.Len(t, pub.messages, 2)
+}
+
+func TestCrawlAndPublishWithoutSeenSet(t *testing.T) {
+	c := &mockCrawler{deals: []crawler.HotDeal{
+		{Id: "1", Title: "Deal 1", Link: "https://example.com/1"},
+	}}
+	pub := &mockPublisher{}
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, nil, nil, nil, time.Minute)
+
+	w.crawlAndPublish(c)
+	w.crawlAndPublish(c)
+	assert.Len(t, pub.messages, 2)
+}
+
+func TestCrawlAndPublishLifecycleEvents(t *testing.T) {
+	c := &mockCrawler{deals: []crawler.HotDeal{
+		{Id: "3", Title: "Deal 3", Link: "https://example.com/3", Status: crawler.StatusActive},
+		{Id: "2", Title: "Deal 2", Link: "https://example.com/2", Status: crawler.StatusActive},
+		{Id: "1", Title: "Deal 1", Link: "https://example.com/1", Status: crawler.StatusActive},
+		{Id: "0", Title: "Deal 0", Link: "https://example.com/0", Status: crawler.StatusEnded},
+	}}
+	pub := &mockPublisher{}
+	seen := dedup.NewSeenSet(cachetest.New(), time.Hour)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, seen, nil, nil, time.Minute)
+
+	// Deals that are already over when first seen are not published
+	result := w.crawlAndPublish(c)
+	assert.Equal(t, 3, result.NewCount)
+	assert.Len(t, pub.messages, 3)
+
+	// Deal 3 sells out, deal 2 is deleted and deal 0 scrolls off the page
+	c.deals = []crawler.HotDeal{
+		{Id: "3", Title: "Deal 3", Link: "https://example.com/3", Status: crawler.StatusSoldOut},
+		{Id: "1", Title: "Deal 1", Link: "https://example.com/1", Status: crawler.StatusActive},
+	}
+	result = w.crawlAndPublish(c)
+	assert.Equal(t, 2, result.LifecycleCount)
+	assert.Len(t, pub.messages, 5)
+
+	var soldOut, deleted DealEvent
+	assert.NoError(t, json.Unmarshal(pub.messages[3], &soldOut))
+	assert.NoError(t, json.Unmarshal(pub.messages[4], &deleted))
+
+	assert.Equal(t, EventSoldOut, soldOut.Event)
+	assert.Equal(t, "3", soldOut.Id)
+	assert.Equal(t, crawler.StatusSoldOut, soldOut.Status)
+	if assert.NotNil(t, soldOut.Previous) {
+		assert.Equal(t, crawler.StatusActive, soldOut.Previous.Status)
+	}
+
+	assert.Equal(t, EventDeleted, deleted.Event)
+	assert.Equal(t, "2", deleted.Id)
+	assert.Equal(t, "Deal 2", deleted.Title)
+	assert.Equal(t, crawler.StatusDeleted, deleted.Status)
+
+	// Nothing changes on the next cycle
+	w.crawlAndPublish(c)
+	assert.Len(t, pub.messages, 5)
+}
+
+func TestCrawlAndPublishTrendingEvents(t *testing.T) {
+	votes := func(v int) *int { return &v }
+	c := &mockCrawler{deals: []crawler.HotDeal{
+		{Id: "3", Title: "Deal 3", Link: "https://example.com/3", Votes: votes(0)},
+		{Id: "2", Title: "Deal 2", Link: "https://example.com/2", Votes: votes(0)},
+		{Id: "1", Title: "Deal 1", Link: "https://example.com/1", Votes: votes(0)},
+	}}
+	pub := &mockPublisher{}
+	cacheSvc := cachetest.New()
+	seen := dedup.NewSeenSet(cacheSvc, time.Hour)
+	tracker := hotness.NewTracker(cacheSvc, time.Hour, 2)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, seen, nil, tracker, time.Minute)
+
+	// New deals without a posting time have no velocity yet and carry no score
+	w.crawlAndPublish(c)
+	if assert.Len(t, pub.messages, 3) {
+		var created DealEvent
+		assert.NoError(t, json.Unmarshal(pub.messages[0], &created))
+		assert.Nil(t, created.Hotness)
+	}
+
+	// Deal 2 takes off
+	c.deals[1].Votes = votes(30)
+	result := w.crawlAndPublish(c)
+	assert.Equal(t, 1, result.TrendingCount)
+	if assert.Len(t, pub.messages, 4) {
+		var trending DealEvent
+		assert.NoError(t, json.Unmarshal(pub.messages[3], &trending))
+		assert.Equal(t, EventTrending, trending.Event)
+		assert.Equal(t, "2", trending.Id)
+		if assert.NotNil(t, trending.Hotness) {
+			assert.InDelta(t, 3.0, *trending.Hotness, 0.001)
+		}
+	}
+
+	// A deal is only announced as trending once
+	c.deals[1].Votes = votes(60)
+	result = w.crawlAndPublish(c)
+	assert.Equal(t, 0, result.TrendingCount)
+	assert.Len(t, pub.messages, 4)
+}
+
+func TestCrawlAndPublishScoresPostedDeals(t *testing.T) {
+	votes := func(v int) *int { return &v }
+	postedAt := time.Now().Add(-10 * time.Minute).Format(time.RFC3339)
+	c := &mockCrawler{deals: []crawler.HotDeal{
+		{Id: "2", Title: "Deal 2", Link: "https://example.com/2", Votes: votes(20), PostedAt: postedAt},
+		{Id: "1", Title: "Deal 1", Link: "https://example.com/1", Votes: votes(20), PostedAt: postedAt},
+	}}
+	pub := &mockPublisher{}
+	cacheSvc := cachetest.New()
+	tracker := hotness.NewTracker(cacheSvc, time.Hour, 2)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, dedup.NewSeenSet(cacheSvc, time.Hour), nil, tracker, time.Minute)
+
+	// The posting time gives new deals a score on their created event
+	w.crawlAndPublish(c)
+	if assert.Len(t, pub.messages, 2) {
+		var created DealEvent
+		assert.NoError(t, json.Unmarshal(pub.messages[0], &created))
+		assert.Equal(t, EventCreated, created.Event)
+		if assert.NotNil(t, created.Hotness) {
+			assert.InDelta(t, 1.0, *created.Hotness, 0.01)
+		}
+	}
+}
+
+func TestCrawlAndPublishCatchUp(t *testing.T) {
+	c := &mockPagedCrawler{pages: [][]crawler.HotDeal{
+		{{Id: "2", Title: "Deal 2", Link: "https://example.com/2"}},
+		{{Id: "1", Title: "Deal 1", Link: "https://example.com/1"}},
+	}}
+	pub := &mockPublisher{}
+	seen := dedup.NewSeenSet(cachetest.New(), time.Hour)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, seen, nil, nil, time.Minute)
+
+	// Without a previous listing only the first page is fetched
+	result := w.crawlAndPublish(c)
+	assert.Equal(t, 1, result.NewCount)
+	assert.Equal(t, 1, c.fetched)
+
+	// Three deals were posted while the worker was down, pushing deal 2 to the third page
+	c.pages = [][]crawler.HotDeal{
+		{{Id: "5", Title: "Deal 5", Link: "https://example.com/5"}},
+		{{Id: "4", Title: "Deal 4", Link: "https://example.com/4"}, {Id: "3", Title: "Deal 3", Link: "https://example.com/3"}},
+		{{Id: "2", Title: "Deal 2", Link: "https://example.com/2"}},
+		{{Id: "1", Title: "Deal 1", Link: "https://example.com/1"}},
+	}
+	c.fetched = 0
+	result = w.crawlAndPublish(c)
+	assert.Equal(t, 3, result.NewCount)
+	assert.Equal(t, 3, c.fetched)
+	assert.Len(t, pub.messages, 4)
+}
+
+func TestCrawlAndPublishEnrichesNewDeals(t *testing.T) {
+	c := &mockDetailCrawler{mockCrawler: mockCrawler{deals: []crawler.HotDeal{
+		{Id: "2", Title: "Deal 2", Link: "https://example.com/2"},
+		{Id: "1", Title: "Deal 1", Link: "https://example.com/1"},
+	}}}
+	pub := &mockPublisher{}
+	seen := dedup.NewSeenSet(cachetest.New(), time.Hour)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, seen, nil, nil, time.Minute)
+
+	// A failed detail page does not hold back the deal
+	result := w.crawlAndPublish(c)
+	assert.Equal(t, 2, result.NewCount)
+	assert.ElementsMatch(t, []string{"1", "2"}, c.enriched)
+	if assert.Len(t, pub.messages, 2) {
+		var failed, enriched DealEvent
+		assert.NoError(t, json.Unmarshal(pub.messages[0], &failed))
+		assert.NoError(t, json.Unmarshal(pub.messages[1], &enriched))
+		assert.Equal(t, "", failed.ShopURL)
+		assert.Equal(t, "https://shop.example.com/1", enriched.ShopURL)
+	}
+
+	// Seen deals are not enriched again, and the enrichment does not make them look updated
+	result = w.crawlAndPublish(c)
+	assert.Equal(t, 0, result.NewCount)
+	assert.Equal(t, 0, result.UpdatedCount)
+	assert.Len(t, c.enriched, 2)
+	assert.Len(t, pub.messages, 2)
+}
+
+func TestCrawlAndPublishLoadsThumbnails(t *testing.T) {
+	c := &mockThumbnailCrawler{mockCrawler: mockCrawler{deals: []crawler.HotDeal{
+		{Id: "1", Title: "Deal 1", Link: "https://example.com/1", ThumbnailLink: "https://example.com/1.jpg"},
+		{Id: "2", Title: "Deal 2", Link: "https://example.com/2"},
+	}}}
+	pub := &mockPublisher{}
+	seen := dedup.NewSeenSet(cachetest.New(), time.Hour)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, seen, nil, nil, time.Minute)
+
+	// Only deals with a thumbnail URL are loaded
+	result := w.crawlAndPublish(c)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"1"}, c.loaded)
+
+	var event DealEvent
+	assert.NoError(t, json.Unmarshal(pub.messages[0], &event))
+	assert.Equal(t, "encoded-1", event.Thumbnail)
+
+	// Seen deals are not loaded again, changed ones are
+	c.deals[1].ThumbnailLink = "https://example.com/2.jpg"
+	c.deals = append(c.deals, crawler.HotDeal{Id: "3", Title: "Deal 3", Link: "https://example.com/3", ThumbnailLink: "https://example.com/3.jpg"})
+	result = w.crawlAndPublish(c)
+	assert.True(t, result.Success)
+	assert.ElementsMatch(t, []string{"1", "2", "3"}, c.loaded)
+}
+
+func TestCrawlAndPublishDropsRejectedThumbnail(t *testing.T) {
+	c := &mockThumbnailCrawler{
+		mockCrawler: mockCrawler{deals: []crawler.HotDeal{
+			{Id: "1", Title: "Deal 1", Link: "https://example.com/1", ThumbnailLink: "https://example.com/pixel.gif"},
+		}},
+		rejected: map[string]bool{"1": true},
+	}
+	pub := &mockPublisher{}
+	seen := dedup.NewSeenSet(cachetest.New(), time.Hour)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, seen, nil, nil, time.Minute)
+
+	// The deal is published without the rejected thumbnail's link
+	result := w.crawlAndPublish(c)
+	assert.True(t, result.Success)
+	assert.Len(t, pub.messages, 1)
+
+	var event DealEvent
+	assert.NoError(t, json.Unmarshal(pub.messages[0], &event))
+	assert.Empty(t, event.ThumbnailLink)
+	assert.Empty(t, event.Thumbnail)
+
+	// The recorded snapshot keeps the link, so the next run is not an update
+	result = w.crawlAndPublish(c)
+	assert.True(t, result.Success)
+	assert.Equal(t, 0, result.UpdatedCount)
+	assert.Len(t, pub.messages, 1)
+}
+
+func TestCrawlAndPublishDeadline(t *testing.T) {
+	c := &mockSlowCrawler{timeout: 50 * time.Millisecond}
+	pub := &mockPublisher{}
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, nil, nil, nil, time.Minute)
+
+	// The crawl fails at the crawler's deadline instead of holding the cycle
+	start := time.Now()
+	result := w.crawlAndPublish(c)
+	assert.False(t, result.Success)
+	assert.ErrorIs(t, result.Error, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// Cancelling the worker's context interrupts a crawl without a deadline
+	ctx, cancel := context.WithCancel(context.Background())
+	c = &mockSlowCrawler{}
+	w = NewWorker(ctx, []crawler.Crawler{c}, pub, nil, nil, nil, time.Minute)
+	time.AfterFunc(50*time.Millisecond, cancel)
+	result = w.crawlAndPublish(c)
+	assert.False(t, result.Success)
+	assert.ErrorIs(t, result.Error, context.Canceled)
+	assert.Empty(t, pub.messages)
+}
+
+func TestCrawlAndPublishUnchangedBoard(t *testing.T) {
+	c := &mockUnchangedCrawler{mockCrawler: mockCrawler{deals: []crawler.HotDeal{
+		{Id: "1", Title: "Deal 1", Link: "https://example.com/1"},
+		{Id: "2", Title: "Deal 2", Link: "https://example.com/2"},
+	}}}
+	pub := &mockPublisher{}
+	seen := dedup.NewSeenSet(cachetest.New(), time.Hour)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, seen, nil, nil, time.Minute)
+
+	result := w.crawlAndPublish(c)
+	assert.True(t, result.Success)
+	assert.Len(t, pub.messages, 2)
+
+	// An unchanged board is a successful run with nothing to publish,
+	// and the recorded listing is kept for the next deletion check
+	result = w.crawlAndPublish(c)
+	assert.True(t, result.Success)
+	assert.Equal(t, 0, result.FetchedCount)
+	assert.Len(t, pub.messages, 2)
+	assert.Equal(t, []string{"1", "2"}, seen.Board("Mock"))
+}
+
+func TestCrawlAndPublishRetriesUnpublishedBoard(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`<html><body>
+			<div class="deal"><a href="/deal/1">Deal 1</a></div>
+			<div class="deal"><a href="/deal/2">Deal 2</a></div>
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	c := crawler.NewUnifiedCrawler(crawler.CrawlerConfig{
+		URL:       server.URL,
+		BaseURL:   server.URL,
+		Provider:  "Test",
+		Selectors: crawler.Selectors{DealList: "div.deal", Title: "a", Link: "a"},
+	}, nil)
+	pub := &mockPublisher{failures: 1}
+	seen := dedup.NewSeenSet(cachetest.New(), time.Hour)
+	w := NewWorker(context.Background(), []crawler.Crawler{c}, pub, seen, nil, nil, time.Minute)
+
+	// The first deal fails to publish
+	w.crawlAndPublish(c)
+	assert.Len(t, pub.messages, 1)
+
+	// The identical page is parsed again and the missing deal published
+	result := w.crawlAndPublish(c)
+	assert.Equal(t, 1, result.NewCount)
+	assert.Len(t, pub.messages, 2)
+
+	// Once everything is published the board is skipped until it changes
+	result = w.crawlAndPublish(c)
+	assert.True(t, result.Success)
+	assert.Equal(t, 0, result.FetchedCount)
+	assert.Len(t, pub.messages, 2)
+	assert.Equal(t, 3, requests)
+}
+
+func TestSetCrawlers(t *testing.T) {
+	first := &mockCrawler{deals: []crawler.HotDeal{
+		{Id: "1", Title: "Deal 1", Link: "https://example.com/1"},
+	}}
+	pub := &mockPublisher{}
+	w := NewWorker(context.Background(), []crawler.Crawler{first}, pub, nil, nil, nil, time.Minute)
+
+	w.startDueCrawlers(time.Now())
+	w.runs.Wait()
+	assert.Equal(t, 1, w.takeResults().TotalDeals)
+
+	// The next run uses the replaced crawlers and keeps the provider's schedule
+	second := &mockCrawler{deals: []crawler.HotDeal{
+		{Id: "2", Title: "Deal 2", Link: "https://example.com/2"},
+		{Id: "3", Title: "Deal 3", Link: "https://example.com/3"},
+	}}
+	w.SetCrawlers([]crawler.Crawler{second})
+
+	w.startDueCrawlers(time.Now())
+	w.runs.Wait()
+	assert.Equal(t, 0, w.takeResults().TotalDeals)
+
+	w.startDueCrawlers(time.Now().Add(2 * time.Minute))
+	w.runs.Wait()
+	results := w.takeResults()
+	assert.Equal(t, 2, results.TotalDeals)
+	assert.Equal(t, 1, results.SuccessfulCrawlers)
+	assert.Len(t, pub.messages, 3)
+}
+
+func TestFindDeleted(t *testing.T) {
+	previous := []string{"5", "4", "3", "2", "1"}
+
+	// New deals push old ones off the bottom of the page
+	assert.Empty(t, findDeleted(previous, []string{"7", "6", "5", "4", "3"}))
+
+	// A deal missing above a deal that is still listed was deleted
+	assert.Equal(t, []string{"4"}, findDeleted(previous, []string{"6", "5", "3", "2"}))
+
+	// Without a previous listing nothing can be judged
+	assert.Empty(t, findDeleted(nil, []string{"1"}))
+}